@@ -0,0 +1,103 @@
+// migrate-storage ย้ายไฟล์แนบที่เก็บบนดิสก์ไปยัง storage ที่ตั้งค่าผ่าน STORAGE_DRIVER
+// และปรับ StorageKey กับ FileURL ของไฟล์แนบให้ชี้ไปยังที่ใหม่ รันซ้ำได้ ไฟล์ที่ย้ายแล้วจะถูกข้าม
+//
+//	go run ./cmd/migrate-storage -from upload/diary [-dry-run] [-delete-source]
+package main
+
+import (
+	"flag"
+	"gofiber-auth/database"
+	"gofiber-auth/models"
+	"gofiber-auth/storage"
+	"log"
+	"path/filepath"
+
+	"github.com/joho/godotenv"
+	"gorm.io/gorm"
+)
+
+func main() {
+	from := flag.String("from", "upload/diary", "local directory that holds the existing attachment files")
+	dryRun := flag.Bool("dry-run", false, "only report what would be migrated")
+	deleteSource := flag.Bool("delete-source", false, "delete local files after they are copied")
+	flag.Parse()
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("Warning: .env file not found")
+	}
+
+	dest, err := storage.FromEnv()
+	if err != nil {
+		log.Fatalf("Invalid storage configuration: %v", err)
+	}
+	src := &storage.Local{Root: *from}
+
+	database.Connect()
+
+	var migrated, skipped, failed int
+	var batch []models.Attachment
+	result := database.DB.Order("id").FindInBatches(&batch, 100, func(tx *gorm.DB, _ int) error {
+		for _, attachment := range batch {
+			key := attachment.StorageKey
+			if key == "" {
+				key = filepath.Base(attachment.FileURL)
+			}
+			location := dest.Location(key)
+			if attachment.StorageKey != "" && attachment.FileURL == location {
+				skipped++
+				continue
+			}
+
+			if *dryRun {
+				log.Printf("attachment %d: %s -> %s", attachment.ID, src.Location(key), location)
+				migrated++
+				continue
+			}
+
+			if err := migrate(src, dest, key, attachment.FileType); err != nil {
+				log.Printf("attachment %d: %v", attachment.ID, err)
+				failed++
+				continue
+			}
+			if err := database.DB.Model(&attachment).Updates(map[string]interface{}{
+				"storage_key": key,
+				"file_url":    location,
+			}).Error; err != nil {
+				log.Printf("attachment %d: failed to update record: %v", attachment.ID, err)
+				failed++
+				continue
+			}
+
+			if *deleteSource && src.Location(key) != location {
+				if err := src.Delete(key); err != nil {
+					log.Printf("attachment %d: failed to delete source file: %v", attachment.ID, err)
+				}
+			}
+			migrated++
+		}
+		return nil
+	})
+	if result.Error != nil {
+		log.Fatalf("Failed to query attachments: %v", result.Error)
+	}
+
+	log.Printf("Storage migration finished: migrated=%d skipped=%d failed=%d dry_run=%t", migrated, skipped, failed, *dryRun)
+}
+
+// migrate คัดลอกไฟล์จากดิสก์ไปยัง storage ปลายทาง ข้ามการคัดลอกหากปลายทางคือไฟล์เดียวกัน
+func migrate(src *storage.Local, dest storage.Storage, key, contentType string) error {
+	if src.Location(key) == dest.Location(key) {
+		return nil
+	}
+
+	reader, info, err := src.Open(key)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	if contentType == "" {
+		contentType = info.ContentType
+	}
+	return dest.Put(key, reader, info.Size, contentType)
+}
@@ -12,14 +12,14 @@ func GetAllStudentsByAdmin(c *fiber.Ctx) error {
 	result := database.DB.Where("role = ?", "student").Find(&students)
 
 	if result.Error != nil {
-		return c.Status(fiber.StatusInternalServerError).SendString("Failed to query students")
+		return errInternal(result.Error, "Failed to query students")
 	}
 
 	if result.RowsAffected == 0 {
-		return c.Status(fiber.StatusNotFound).SendString("No students found")
+		return errNotFound("No students found")
 	}
 
-	return c.JSON(students)
+	return respond(c, fiber.StatusOK, "Students retrieved successfully", students)
 }
 
 func GetAllTeacherByAdmin(c *fiber.Ctx) error {
@@ -27,12 +27,12 @@ func GetAllTeacherByAdmin(c *fiber.Ctx) error {
 	result := database.DB.Where("role = ?", "advisor").Find(&teachers)
 
 	if result.Error != nil {
-		return c.Status(fiber.StatusInternalServerError).SendString("Failed to query teachers")
+		return errInternal(result.Error, "Failed to query teachers")
 	}
 
 	if result.RowsAffected == 0 {
-		return c.Status(fiber.StatusNotFound).SendString("No teachers found")
+		return errNotFound("No teachers found")
 	}
 
-	return c.JSON(teachers)
+	return respond(c, fiber.StatusOK, "Teachers retrieved successfully", teachers)
 }
@@ -0,0 +1,229 @@
+package controllers
+
+import (
+	"gofiber-auth/database"
+	"gofiber-auth/models"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+type CreateAdvisorNoteRequest struct {
+	StudentID    uint           `json:"student_id" validate:"required"`
+	DiaryID      *uint          `json:"diary_id"`
+	Title        string         `json:"title" validate:"required,max=255"`
+	ContentHTML  string         `json:"content_html" validate:"required"`
+	ContentDelta datatypes.JSON `json:"content_delta"`
+	Shared       bool           `json:"shared"`
+}
+
+type UpdateAdvisorNoteRequest struct {
+	DiaryID      *uint          `json:"diary_id"`
+	Title        string         `json:"title" validate:"required,max=255"`
+	ContentHTML  string         `json:"content_html" validate:"required"`
+	ContentDelta datatypes.JSON `json:"content_delta"`
+	Shared       bool           `json:"shared"`
+}
+
+type AdvisorNoteQuery struct {
+	StudentID uint   `query:"student_id"`
+	DiaryID   uint   `query:"diary_id"`
+	Q         string `query:"q" validate:"omitempty,max=100"`
+	Page      int    `query:"page"`
+	Limit     int    `query:"limit"`
+}
+
+// visibleAdvisorNotes คือบันทึกที่อาจารย์เห็นได้: บันทึกของตนเอง และบันทึกที่แบ่งปันโดยอาจารย์ที่ปรึกษาคนอื่นของนิสิตที่ตนดูแลอยู่
+func visibleAdvisorNotes(advisorID uint) *gorm.DB {
+	return database.DB.Model(&models.AdvisorNote{}).
+		Where("advisor_notes.author_id = ? OR (advisor_notes.shared = ? AND EXISTS ("+
+			"SELECT 1 FROM student_advisors WHERE student_advisors.student_id = advisor_notes.student_id AND student_advisors.advisor_id = ?))",
+			advisorID, true, advisorID)
+}
+
+// checkNoteDiary ตรวจว่าบันทึกประจำวันที่อ้างถึงเป็นของนิสิตคนเดียวกับ note
+func checkNoteDiary(diaryID *uint, studentID uint) error {
+	if diaryID == nil {
+		return nil
+	}
+	var diary models.Diary
+	if err := database.DB.Select("id", "student_id").First(&diary, *diaryID).Error; err != nil {
+		return errLookup(err, "Diary not found")
+	}
+	if diary.StudentID != studentID {
+		return errBadRequest("Diary does not belong to this student").WithTH("บันทึกนี้ไม่ใช่ของนิสิตคนนี้")
+	}
+	return nil
+}
+
+// ownAdvisorNote โหลด note ที่ผู้ใช้เป็นผู้เขียน ผู้ที่ไม่ใช่ผู้เขียนแก้ไขหรือลบไม่ได้
+func ownAdvisorNote(c *fiber.Ctx, user *models.User) (models.AdvisorNote, error) {
+	var note models.AdvisorNote
+	if err := visibleAdvisorNotes(user.ID).First(&note, c.Params("id")).Error; err != nil {
+		return note, errLookup(err, "Advisor note not found")
+	}
+	if note.AuthorID != user.ID {
+		return note, errForbidden("Only the author can modify this note")
+	}
+	return note, nil
+}
+
+// GetAdvisorNotes ค้นหาบันทึกของอาจารย์ กรองตามนิสิต บันทึกประจำวัน หรือคำค้นในหัวข้อและเนื้อหา
+func GetAdvisorNotes(c *fiber.Ctx) error {
+	user := currentUser(c)
+	if user == nil {
+		return errUnauthorized("Unauthorized")
+	}
+
+	var query AdvisorNoteQuery
+	if err := bindQuery(c, &query); err != nil {
+		return err
+	}
+	page, limit := pageParams(query.Page, query.Limit)
+
+	dbQuery := visibleAdvisorNotes(user.ID)
+	if query.StudentID != 0 {
+		dbQuery = dbQuery.Where("advisor_notes.student_id = ?", query.StudentID)
+	}
+	if query.DiaryID != 0 {
+		dbQuery = dbQuery.Where("advisor_notes.diary_id = ?", query.DiaryID)
+	}
+	if q := strings.TrimSpace(query.Q); q != "" {
+		pattern := "%" + strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(q) + "%"
+		dbQuery = dbQuery.Where("advisor_notes.title LIKE ? OR advisor_notes.content_html LIKE ?", pattern, pattern)
+	}
+
+	var total int64
+	if err := dbQuery.Count(&total).Error; err != nil {
+		return errInternal(err, "Failed to count advisor notes")
+	}
+
+	notes := []models.AdvisorNote{}
+	if err := dbQuery.
+		Preload("Author").
+		Order("advisor_notes.updated_at DESC, advisor_notes.id DESC").
+		Offset((page - 1) * limit).
+		Limit(limit).
+		Find(&notes).Error; err != nil {
+		return errInternal(err, "Failed to query advisor notes")
+	}
+
+	return respondMeta(c, fiber.StatusOK, "Advisor notes retrieved successfully", notes, fiber.Map{
+		"count": len(notes),
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+}
+
+func GetAdvisorNote(c *fiber.Ctx) error {
+	user := currentUser(c)
+	if user == nil {
+		return errUnauthorized("Unauthorized")
+	}
+
+	var note models.AdvisorNote
+	if err := visibleAdvisorNotes(user.ID).Preload("Author").First(&note, c.Params("id")).Error; err != nil {
+		return errLookup(err, "Advisor note not found")
+	}
+
+	return respond(c, fiber.StatusOK, "Advisor note retrieved successfully", note)
+}
+
+func CreateAdvisorNote(c *fiber.Ctx) error {
+	user := currentUser(c)
+	if user == nil {
+		return errUnauthorized("Unauthorized")
+	}
+
+	var req CreateAdvisorNoteRequest
+	if err := bindBody(c, &req); err != nil {
+		return err
+	}
+
+	var link models.StudentAdvisor
+	result := database.DB.Where("advisor_id = ? AND student_id = ?", user.ID, req.StudentID).Limit(1).Find(&link)
+	if result.Error != nil {
+		return errInternal(result.Error, "Failed to check advisor relationship")
+	}
+	if result.RowsAffected == 0 {
+		return errForbidden("You are not an advisor of this student")
+	}
+
+	if err := checkNoteDiary(req.DiaryID, req.StudentID); err != nil {
+		return err
+	}
+
+	note := models.AdvisorNote{
+		StudentAdvisorID: link.ID,
+		AuthorID:         user.ID,
+		StudentID:        req.StudentID,
+		DiaryID:          req.DiaryID,
+		Title:            req.Title,
+		ContentHTML:      req.ContentHTML,
+		ContentDelta:     req.ContentDelta,
+		Shared:           req.Shared,
+	}
+	if err := database.DB.Create(&note).Error; err != nil {
+		return errInternal(err, "Failed to create advisor note")
+	}
+	note.Author = *user
+
+	return respond(c, fiber.StatusCreated, "Advisor note created successfully", note)
+}
+
+func UpdateAdvisorNote(c *fiber.Ctx) error {
+	user := currentUser(c)
+	if user == nil {
+		return errUnauthorized("Unauthorized")
+	}
+
+	note, err := ownAdvisorNote(c, user)
+	if err != nil {
+		return err
+	}
+
+	var req UpdateAdvisorNoteRequest
+	if err := bindBody(c, &req); err != nil {
+		return err
+	}
+	if err := checkNoteDiary(req.DiaryID, note.StudentID); err != nil {
+		return err
+	}
+
+	if err := database.DB.Model(&note).Updates(map[string]interface{}{
+		"diary_id":      req.DiaryID,
+		"title":         req.Title,
+		"content_html":  req.ContentHTML,
+		"content_delta": req.ContentDelta,
+		"shared":        req.Shared,
+	}).Error; err != nil {
+		return errInternal(err, "Failed to update advisor note")
+	}
+
+	if err := database.DB.Preload("Author").First(&note, note.ID).Error; err != nil {
+		return errInternal(err, "Failed to reload advisor note")
+	}
+
+	return respond(c, fiber.StatusOK, "Advisor note updated successfully", note)
+}
+
+func DeleteAdvisorNote(c *fiber.Ctx) error {
+	user := currentUser(c)
+	if user == nil {
+		return errUnauthorized("Unauthorized")
+	}
+
+	note, err := ownAdvisorNote(c, user)
+	if err != nil {
+		return err
+	}
+
+	if err := database.DB.Delete(&note).Error; err != nil {
+		return errInternal(err, "Failed to delete advisor note")
+	}
+
+	return respond(c, fiber.StatusOK, "Advisor note deleted successfully", nil)
+}
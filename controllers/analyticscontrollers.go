@@ -0,0 +1,340 @@
+package controllers
+
+import (
+	"fmt"
+	"gofiber-auth/database"
+	"gofiber-auth/models"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+const dateLayout = "2006-01-02"
+
+type MoodSeriesQuery struct {
+	Granularity string `query:"granularity" validate:"omitempty,oneof=day week month"`
+	StartDate   string `query:"startDate" validate:"omitempty,date"`
+	EndDate     string `query:"endDate" validate:"omitempty,date"`
+}
+
+// MoodSeriesPoint คือสถิติอารมณ์ของหนึ่งช่วงเวลา
+type MoodSeriesPoint struct {
+	PeriodStart     string   `json:"period_start"`
+	PeriodEnd       string   `json:"period_end"`
+	Entries         int64    `json:"entries"`
+	DaysWithEntries int64    `json:"days_with_entries"`
+	ExpectedDays    int64    `json:"expected_days"`
+	MissingDays     int64    `json:"missing_days"`
+	AverageScore    *float64 `json:"average_score"`
+	Variance        *float64 `json:"variance"`
+	// AverageSentiment คือค่าเฉลี่ยของ sentiment ที่วิเคราะห์จากเนื้อหา ใช้เทียบกับ AverageScore ที่นิสิตเลือกเอง
+	AverageSentiment *float64 `json:"average_sentiment"`
+	// SentimentMismatches คือจำนวนบันทึกที่อารมณ์ที่เลือกกับ sentiment ของเนื้อหาไปคนละทิศทาง
+	SentimentMismatches int64 `json:"sentiment_mismatches"`
+}
+
+// maxSeriesPeriods คือจำนวนช่วงเวลาสูงสุดของ mood series ต่อคำขอ เช่น 366 วันเมื่อ granularity เป็น day
+const maxSeriesPeriods = 366
+
+// dateRange คืนค่าช่วงวันที่จาก query โดยค่าเริ่มต้นคือ 30 วันล่าสุด
+// คืนค่า 400 หากวันที่ผิดรูปแบบหรือวันเริ่มต้นอยู่หลังวันสิ้นสุด
+func (q MoodSeriesQuery) dateRange() (time.Time, time.Time, error) {
+	loc, _ := time.LoadLocation("Asia/Bangkok")
+	now := time.Now().In(loc)
+	end := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if q.EndDate != "" {
+		parsed, err := time.Parse(dateLayout, q.EndDate)
+		if err != nil {
+			return time.Time{}, time.Time{}, errBadRequest("endDate must be in YYYY-MM-DD format").WithTH("รูปแบบวันสิ้นสุดไม่ถูกต้อง")
+		}
+		end = parsed
+	}
+	start := end.AddDate(0, 0, -29)
+	if q.StartDate != "" {
+		parsed, err := time.Parse(dateLayout, q.StartDate)
+		if err != nil {
+			return time.Time{}, time.Time{}, errBadRequest("startDate must be in YYYY-MM-DD format").WithTH("รูปแบบวันเริ่มต้นไม่ถูกต้อง")
+		}
+		start = parsed
+	}
+	if start.After(end) {
+		return time.Time{}, time.Time{}, errBadRequest("startDate must not be after endDate").WithTH("วันเริ่มต้นต้องไม่อยู่หลังวันสิ้นสุด")
+	}
+	return start, end, nil
+}
+
+// seriesRange คืนค่าช่วงวันที่ของ mood series ซึ่งยาวได้ไม่เกิน maxSeriesPeriods ช่วงตาม granularity
+func (q MoodSeriesQuery) seriesRange() (time.Time, time.Time, error) {
+	start, end, err := q.dateRange()
+	if err != nil {
+		return start, end, err
+	}
+	granularity := q.granularity()
+	limit := periodStart(start, granularity)
+	for i := 0; i < maxSeriesPeriods; i++ {
+		limit = nextPeriod(limit, granularity)
+	}
+	if !end.Before(limit) {
+		return time.Time{}, time.Time{}, errBadRequest(fmt.Sprintf("Date range must not exceed %d periods of %s", maxSeriesPeriods, granularity)).
+			WithTH(fmt.Sprintf("ช่วงวันที่ต้องไม่เกิน %d ช่วง", maxSeriesPeriods))
+	}
+	return start, end, nil
+}
+
+func (q MoodSeriesQuery) granularity() string {
+	if q.Granularity == "" {
+		return "day"
+	}
+	return q.Granularity
+}
+
+// periodExpr คืนค่านิพจน์ SQL ที่แปลง diary_date เป็นวันเริ่มต้นของช่วงเวลา
+func periodExpr(granularity string) string {
+	switch granularity {
+	case "week":
+		return "DATE_FORMAT(DATE_SUB(diaries.diary_date, INTERVAL WEEKDAY(diaries.diary_date) DAY), '%Y-%m-%d')"
+	case "month":
+		return "DATE_FORMAT(diaries.diary_date, '%Y-%m-01')"
+	}
+	return "DATE_FORMAT(diaries.diary_date, '%Y-%m-%d')"
+}
+
+// periodStart คืนค่าวันเริ่มต้นของช่วงเวลาที่ t อยู่ ให้ตรงกับ periodExpr
+func periodStart(t time.Time, granularity string) time.Time {
+	switch granularity {
+	case "week":
+		offset := (int(t.Weekday()) + 6) % 7
+		return t.AddDate(0, 0, -offset)
+	case "month":
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	}
+	return t
+}
+
+func nextPeriod(t time.Time, granularity string) time.Time {
+	switch granularity {
+	case "week":
+		return t.AddDate(0, 0, 7)
+	case "month":
+		return t.AddDate(0, 1, 0)
+	}
+	return t.AddDate(0, 0, 1)
+}
+
+// buildMoodSeries รวมสถิติอารมณ์ด้วย SQL ตามขอบเขตที่ scope กำหนด แล้วเติมช่วงเวลาที่ไม่มีบันทึก
+// cohortSize คือจำนวนนิสิตในขอบเขต ใช้คำนวณจำนวนวันที่ขาดการเขียน
+func buildMoodSeries(scope func(*gorm.DB) *gorm.DB, cohortSize int64, granularity string, start, end time.Time) ([]MoodSeriesPoint, error) {
+	period := periodExpr(granularity)
+	mid := neutralMoodScore()
+
+	var rows []struct {
+		Period              string
+		Entries             int64
+		DaysWithEntries     int64
+		AverageScore        *float64
+		Variance            *float64
+		AverageSentiment    *float64
+		SentimentMismatches int64
+	}
+
+	err := scope(database.DB.Model(&models.Diary{})).
+		Joins("LEFT JOIN mood_scales ON mood_scales.mood_key = diaries.status").
+		Where("diaries.diary_date BETWEEN ? AND ?", start.Format(dateLayout), end.Format(dateLayout)).
+		Select(period+" AS period, "+
+			"COUNT(*) AS entries, "+
+			"COUNT(DISTINCT diaries.student_id, diaries.diary_date) AS days_with_entries, "+
+			"AVG(mood_scales.score) AS average_score, "+
+			"VAR_POP(mood_scales.score) AS variance, "+
+			"AVG(diaries.sentiment_score) AS average_sentiment, "+
+			"COALESCE(SUM(CASE WHEN (mood_scales.score > ? AND diaries.sentiment_score <= ?) "+
+			"OR (mood_scales.score < ? AND diaries.sentiment_score >= ?) THEN 1 ELSE 0 END), 0) AS sentiment_mismatches",
+			mid, -sentimentMismatchThreshold, mid, sentimentMismatchThreshold).
+		Group(period).
+		Order("period ASC").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	byPeriod := make(map[string]int)
+	for i, row := range rows {
+		byPeriod[row.Period] = i
+	}
+
+	series := []MoodSeriesPoint{}
+	for p := periodStart(start, granularity); !p.After(end); p = nextPeriod(p, granularity) {
+		from, to := p, nextPeriod(p, granularity).AddDate(0, 0, -1)
+		if from.Before(start) {
+			from = start
+		}
+		if to.After(end) {
+			to = end
+		}
+		days := int64(to.Sub(from).Hours()/24) + 1
+
+		point := MoodSeriesPoint{
+			PeriodStart:  p.Format(dateLayout),
+			PeriodEnd:    to.Format(dateLayout),
+			ExpectedDays: days * cohortSize,
+		}
+		if i, ok := byPeriod[p.Format(dateLayout)]; ok {
+			row := rows[i]
+			point.Entries = row.Entries
+			point.DaysWithEntries = row.DaysWithEntries
+			point.AverageScore = row.AverageScore
+			point.Variance = row.Variance
+			point.AverageSentiment = row.AverageSentiment
+			point.SentimentMismatches = row.SentimentMismatches
+		}
+		point.MissingDays = point.ExpectedDays - point.DaysWithEntries
+		if point.MissingDays < 0 {
+			point.MissingDays = 0
+		}
+		series = append(series, point)
+	}
+
+	return series, nil
+}
+
+func respondMoodSeries(c *fiber.Ctx, granularity string, start, end time.Time, scope fiber.Map, cohortSize int64, series []MoodSeriesPoint) error {
+	return respondMeta(c, fiber.StatusOK, "Mood series retrieved successfully", series, fiber.Map{
+		"scope":       scope,
+		"granularity": granularity,
+		"start_date":  start.Format(dateLayout),
+		"end_date":    end.Format(dateLayout),
+		"cohort_size": cohortSize,
+	})
+}
+
+func GetStudentMoodSeries(c *fiber.Ctx) error {
+	user := currentUser(c)
+	if user == nil {
+		return errUnauthorized("Unauthorized")
+	}
+
+	var scope struct {
+		StudentID uint `query:"student_id" validate:"required"`
+	}
+	if err := bindQuery(c, &scope); err != nil {
+		return err
+	}
+	var query MoodSeriesQuery
+	if err := bindQuery(c, &query); err != nil {
+		return err
+	}
+	start, end, err := query.seriesRange()
+	if err != nil {
+		return err
+	}
+
+	allowed, err := canViewStudent(user, scope.StudentID)
+	if err != nil {
+		return errInternal(err, "Failed to check student access")
+	}
+	if !allowed {
+		return errForbidden("You cannot view this student's data")
+	}
+
+	series, err := buildMoodSeries(func(db *gorm.DB) *gorm.DB {
+		return db.Where("diaries.student_id = ?", scope.StudentID)
+	}, 1, query.granularity(), start, end)
+	if err != nil {
+		return errInternal(err, "Failed to build mood series")
+	}
+
+	// sentiment เป็นข้อมูลสำหรับอาจารย์ นิสิตที่ดูสถิติของตนเองจะไม่เห็นค่านี้
+	if user.Role == "student" {
+		for i := range series {
+			series[i].AverageSentiment = nil
+			series[i].SentimentMismatches = 0
+		}
+	}
+
+	return respondMoodSeries(c, query.granularity(), start, end, fiber.Map{"student_id": scope.StudentID}, 1, series)
+}
+
+func GetGroupMoodSeries(c *fiber.Ctx) error {
+	user := currentUser(c)
+	if user == nil {
+		return errUnauthorized("Unauthorized")
+	}
+
+	var scope struct {
+		GroupID uint `query:"group_id" validate:"required"`
+	}
+	if err := bindQuery(c, &scope); err != nil {
+		return err
+	}
+	var query MoodSeriesQuery
+	if err := bindQuery(c, &query); err != nil {
+		return err
+	}
+	start, end, err := query.seriesRange()
+	if err != nil {
+		return err
+	}
+
+	var group models.Group
+	if err := database.DB.First(&group, scope.GroupID).Error; err != nil {
+		return errLookup(err, "Group not found")
+	}
+	if !canViewGroup(user, group) {
+		return errForbidden("You cannot view this group's data")
+	}
+
+	var cohortSize int64
+	if err := database.DB.Model(&models.StudentGroup{}).Where("group_id = ?", group.ID).Count(&cohortSize).Error; err != nil {
+		return errInternal(err, "Failed to count group members")
+	}
+
+	series, err := buildMoodSeries(func(db *gorm.DB) *gorm.DB {
+		return db.Joins("JOIN student_groups ON student_groups.student_id = diaries.student_id").
+			Where("student_groups.group_id = ?", group.ID)
+	}, cohortSize, query.granularity(), start, end)
+	if err != nil {
+		return errInternal(err, "Failed to build mood series")
+	}
+
+	return respondMoodSeries(c, query.granularity(), start, end, fiber.Map{"group_id": group.ID, "group_name": group.Name}, cohortSize, series)
+}
+
+func GetAdvisorMoodSeries(c *fiber.Ctx) error {
+	user := currentUser(c)
+	if user == nil {
+		return errUnauthorized("Unauthorized")
+	}
+
+	var scope ScopedAdvisorQuery
+	if err := bindQuery(c, &scope); err != nil {
+		return err
+	}
+	var query MoodSeriesQuery
+	if err := bindQuery(c, &query); err != nil {
+		return err
+	}
+	start, end, err := query.seriesRange()
+	if err != nil {
+		return err
+	}
+	advisorID, err := scopedAdvisorID(user, scope.AdvisorID)
+	if err != nil {
+		return err
+	}
+	scope.AdvisorID = advisorID
+
+	var cohortSize int64
+	if err := database.DB.Model(&models.StudentAdvisor{}).Where("advisor_id = ?", scope.AdvisorID).Count(&cohortSize).Error; err != nil {
+		return errInternal(err, "Failed to count supervised students")
+	}
+
+	series, err := buildMoodSeries(func(db *gorm.DB) *gorm.DB {
+		return db.Joins("JOIN student_advisors ON student_advisors.student_id = diaries.student_id").
+			Where("student_advisors.advisor_id = ?", scope.AdvisorID)
+	}, cohortSize, query.granularity(), start, end)
+	if err != nil {
+		return errInternal(err, "Failed to build mood series")
+	}
+
+	return respondMoodSeries(c, query.granularity(), start, end, fiber.Map{"advisor_id": scope.AdvisorID}, cohortSize, series)
+}
@@ -5,7 +5,10 @@ import (
 	"fmt"
 	"gofiber-auth/database"
 	"gofiber-auth/models"
-	"os"
+	"gofiber-auth/storage"
+	"log"
+	"mime"
+	"mime/multipart"
 	"path/filepath"
 	"strconv"
 	"strings"
@@ -14,35 +17,52 @@ import (
 	"github.com/google/uuid"
 )
 
+// attachmentKey คืนค่า key ของไฟล์แนบใน storage ไฟล์เก่าที่ยังไม่ได้ย้ายใช้ชื่อไฟล์จาก FileURL
+// ซึ่งตรงกับ key ใน Local storage ที่ root เป็น upload/diary
+func attachmentKey(attachment models.Attachment) string {
+	if attachment.StorageKey != "" {
+		return attachment.StorageKey
+	}
+	return filepath.Base(attachment.FileURL)
+}
+
+// UploadAttachment แนบไฟล์กับบันทึก เฉพาะนิสิตเจ้าของบันทึก
 func UploadAttachment(c *fiber.Ctx) error {
-	diaryID, err := strconv.Atoi(c.FormValue("diary_id"))
-	if err != nil {
-		return c.Status(400).JSON(fiber.Map{
-			"error": "diary_id ไม่ถูกต้อง",
-		})
+	user := currentUser(c)
+	if user == nil {
+		return errUnauthorized("Unauthorized")
+	}
+
+	var input struct {
+		DiaryID uint `form:"diary_id" validate:"required"`
+	}
+	if err := c.BodyParser(&input); err != nil {
+		return errBadRequest("Invalid diary_id").WithTH("diary_id ไม่ถูกต้อง")
+	}
+	if err := validateRequest(&input); err != nil {
+		return err
+	}
+	diaryID := input.DiaryID
+
+	var diary models.Diary
+	if err := database.DB.Select("id", "student_id").First(&diary, diaryID).Error; err != nil {
+		return errLookup(err, "Diary not found")
+	}
+	if diary.StudentID != user.ID {
+		return errForbidden("You can only attach files to your own diary")
 	}
 
 	form, err := c.MultipartForm()
 	if err != nil {
-		return c.Status(400).JSON(fiber.Map{
-			"error": "ไม่สามารถอ่านไฟล์ได้",
-		})
+		return errBadRequest("Cannot read uploaded files").WithTH("ไม่สามารถอ่านไฟล์ได้")
 	}
 
 	files := form.File["files"]
 	if len(files) == 0 {
-		return c.Status(400).JSON(fiber.Map{
-			"error": "ไม่มีไฟล์ที่อัปโหลด",
-		})
-	}
-
-	savePath := fmt.Sprintf("upload/diary/")
-	if err := os.MkdirAll(savePath, os.ModePerm); err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"error": "ไม่สามารถสร้างโฟลเดอร์ได้",
-		})
+		return errBadRequest("No files uploaded").WithTH("ไม่มีไฟล์ที่อัปโหลด")
 	}
 
+	store := storage.Default()
 	var uploadedFiles []fiber.Map
 	var attachments []models.Attachment
 
@@ -69,7 +89,7 @@ func UploadAttachment(c *fiber.Ctx) error {
 		}
 
 		if contentType != "application/pdf" {
-			return errors.New("only PDF files are allowed")
+			return errBadRequest("only PDF files are allowed").WithTH("อนุญาตเฉพาะไฟล์ PDF เท่านั้น")
 		}
 
 		isAllowed := false
@@ -81,110 +101,232 @@ func UploadAttachment(c *fiber.Ctx) error {
 		}
 
 		if !isAllowed {
-			return c.Status(400).JSON(fiber.Map{
-				"error": fmt.Sprintf("ไฟล์ %s ไม่ได้รับอนุญาต (ประเภท: %s)", file.Filename, contentType),
-			})
+			return errBadRequest(fmt.Sprintf("File %s is not allowed (type: %s)", file.Filename, contentType)).
+				WithTH(fmt.Sprintf("ไฟล์ %s ไม่ได้รับอนุญาต (ประเภท: %s)", file.Filename, contentType))
 		}
 
-		newFileName := uuid.New().String() + "_" + file.Filename
-		fullPath := filepath.Join(savePath, newFileName)
+		newFileName := uuid.New().String() + "_" + filepath.Base(file.Filename)
+		fullPath := store.Location(newFileName)
 
-		if err := c.SaveFile(file, fullPath); err != nil {
-			return c.Status(500).JSON(fiber.Map{
-				"error": fmt.Sprintf("ไม่สามารถบันทึกไฟล์ %s ได้: %v", file.Filename, err),
-			})
+		if err := saveAttachmentFile(store, file, newFileName, contentType); err != nil {
+			removeStoredFiles(store, uploadedFiles)
+			return errInternal(err, fmt.Sprintf("Cannot save file %s", file.Filename)).
+				WithTH(fmt.Sprintf("ไม่สามารถบันทึกไฟล์ %s ได้", file.Filename))
 		}
 
 		attachment := models.Attachment{
-			DiaryID:  uint(diaryID),
-			FileURL:  fullPath,
-			FileName: file.Filename,
-			FileType: contentType,
+			DiaryID:    uint(diaryID),
+			FileURL:    fullPath,
+			StorageKey: newFileName,
+			FileName:   file.Filename,
+			FileType:   contentType,
 		}
 
 		attachments = append(attachments, attachment)
 		uploadedFiles = append(uploadedFiles, fiber.Map{
 			"original_name": file.Filename,
 			"saved_name":    newFileName,
-			"file_path":     fullPath,
 			"file_size":     file.Size,
 			"content_type":  contentType,
 		})
 	}
 
 	if err := database.DB.Create(&attachments).Error; err != nil {
-		for _, file := range uploadedFiles {
-			os.Remove(file["file_path"].(string))
-		}
-		return c.Status(500).JSON(fiber.Map{
-			"error": fmt.Sprintf("ไม่สามารถบันทึกข้อมูลลงฐานข้อมูลได้: %v", err),
-		})
+		removeStoredFiles(store, uploadedFiles)
+		return errInternal(err, "Cannot save attachments to database").WithTH("ไม่สามารถบันทึกข้อมูลลงฐานข้อมูลได้")
 	}
 
-	return c.JSON(fiber.Map{
-		"message":        "อัปโหลดสำเร็จ",
-		"uploaded_files": uploadedFiles,
-		"total_files":    len(uploadedFiles),
+	for i, attachment := range attachments {
+		url, expiresAt := signedAttachmentURL(attachment.ID)
+		uploadedFiles[i]["id"] = attachment.ID
+		uploadedFiles[i]["url"] = url
+		uploadedFiles[i]["url_expires_at"] = expiresAt
+	}
+
+	return respondMeta(c, fiber.StatusOK, "อัปโหลดสำเร็จ", uploadedFiles, fiber.Map{
+		"total_files": len(uploadedFiles),
 	})
 }
 
+func saveAttachmentFile(store storage.Storage, file *multipart.FileHeader, key, contentType string) error {
+	src, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	return store.Put(key, src, file.Size, contentType)
+}
+
+// removeStoredFiles ลบไฟล์ที่อัปโหลดไปแล้วเมื่อการอัปโหลดทั้งชุดล้มเหลว
+func removeStoredFiles(store storage.Storage, uploadedFiles []fiber.Map) {
+	for _, file := range uploadedFiles {
+		key := file["saved_name"].(string)
+		if err := store.Delete(key); err != nil {
+			log.Printf("Warning: ไม่สามารถลบไฟล์ %s ได้: %v", store.Location(key), err)
+		}
+	}
+}
+
+// DeleteAttachment ลบไฟล์แนบได้เฉพาะนิสิตเจ้าของบันทึกและผู้ดูแลระบบ
 func DeleteAttachment(c *fiber.Ctx) error {
+	user := currentUser(c)
+	if user == nil {
+		return errUnauthorized("Unauthorized")
+	}
+
 	attachmentID, err := strconv.Atoi(c.Params("id"))
 	if err != nil {
-		return c.Status(400).JSON(fiber.Map{
-			"error": "ID ไม่ถูกต้อง",
-		})
+		return errBadRequest("Invalid ID").WithTH("ID ไม่ถูกต้อง")
 	}
 
 	var attachment models.Attachment
 	if err := database.DB.First(&attachment, attachmentID).Error; err != nil {
-		return c.Status(404).JSON(fiber.Map{
-			"error": "ไม่พบไฟล์แนบ",
-		})
+		return errLookup(err, "Attachment not found")
 	}
 
-	if err := os.Remove(attachment.FileURL); err != nil {
-		fmt.Printf("Warning: ไม่สามารถลบไฟล์ %s ได้: %v\n", attachment.FileURL, err)
+	if user.Role != "admin" {
+		var diary models.Diary
+		if err := database.DB.Select("id", "student_id").First(&diary, attachment.DiaryID).Error; err != nil {
+			return errLookup(err, "Diary not found")
+		}
+		if diary.StudentID != user.ID {
+			return errForbidden("You cannot delete this attachment")
+		}
+	}
+
+	if err := storage.Default().Delete(attachmentKey(attachment)); err != nil {
+		log.Printf("[%s] Warning: ไม่สามารถลบไฟล์ %s ได้: %v", requestID(c), attachment.FileURL, err)
 	}
 
 	if err := database.DB.Delete(&attachment).Error; err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"error": "ไม่สามารถลบข้อมูลจากฐานข้อมูลได้",
-		})
+		return errInternal(err, "Cannot delete attachment from database").WithTH("ไม่สามารถลบข้อมูลจากฐานข้อมูลได้")
 	}
 
-	return c.JSON(fiber.Map{
-		"message": "ลบไฟล์สำเร็จ",
-	})
+	return respond(c, fiber.StatusOK, "ลบไฟล์สำเร็จ", nil)
 }
 
+// GetAttachmentsByDiaryId คืนค่าไฟล์แนบของบันทึกตาม query ID (รหัสบันทึก) ให้ผู้ที่มองเห็นบันทึก
 func GetAttachmentsByDiaryId(c *fiber.Ctx) error {
-	idStr := c.Query("ID")
-	if idStr == "" {
-		return c.Status(400).JSON(fiber.Map{"error": "id is required"})
+	user := currentUser(c)
+	if user == nil {
+		return errUnauthorized("Unauthorized")
 	}
 
-	id64, err := strconv.ParseUint(idStr, 10, 64)
+	var query struct {
+		ID uint `query:"ID" validate:"required"`
+	}
+	if err := bindQuery(c, &query); err != nil {
+		return err
+	}
+
+	var diary models.Diary
+	if err := database.DB.Select("id", "student_id", "is_shared").First(&diary, query.ID).Error; err != nil {
+		return errLookup(err, "Diary not found")
+	}
+	visible, err := canViewDiary(user, diary)
 	if err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "invalid id"})
+		return errInternal(err, "Failed to check diary access")
+	}
+	if !visible {
+		return errForbidden("You cannot access this diary")
 	}
-	id := uint(id64)
 
 	var attachments []models.Attachment
 	result := database.DB.
-		Where("ID = ?", id).
+		Where("diary_id = ?", diary.ID).
+		Order("id ASC").
 		Find(&attachments)
 
 	if result.Error != nil {
-		return c.Status(500).JSON(fiber.Map{"error": result.Error.Error()})
+		return errInternal(result.Error, "Failed to retrieve attachments")
 	}
 
 	if len(attachments) == 0 {
-		return c.Status(404).JSON(fiber.Map{"error": "No attachments found for this Diary ID"})
+		return errNotFound("No attachments found for this Diary ID")
+	}
+
+	attachAttachmentURLs(attachments)
+	return respond(c, fiber.StatusOK, "Attachments retrieved successfully", attachments)
+}
+
+// GetAttachmentURL ออก URL ดาวน์โหลดแบบลงนามให้ผู้ที่มองเห็นบันทึก สำหรับใช้ใน <img> หรือ <video> ที่ส่ง Authorization header ไม่ได้
+func GetAttachmentURL(c *fiber.Ctx) error {
+	user := currentUser(c)
+	if user == nil {
+		return errUnauthorized("Unauthorized")
 	}
 
-	return c.JSON(fiber.Map{
-		"message":     "Attachments retrieved successfully",
-		"attachments": attachments,
+	var attachment models.Attachment
+	if err := database.DB.First(&attachment, c.Params("id")).Error; err != nil {
+		return errLookup(err, "Attachment not found")
+	}
+	visible, err := canViewAttachment(user, attachment)
+	if err != nil {
+		return errInternal(err, "Failed to check diary access")
+	}
+	if !visible {
+		return errForbidden("You cannot access this attachment")
+	}
+
+	url, expiresAt := signedAttachmentURL(attachment.ID)
+	return respond(c, fiber.StatusOK, "Attachment URL created successfully", fiber.Map{
+		"url":        url,
+		"expires_at": expiresAt,
 	})
 }
+
+// DownloadAttachment ส่งไฟล์แนบจาก storage แบบ stream ผู้เรียกต้องมี URL ที่ลงนามและยังไม่หมดอายุ
+// หรือเข้าสู่ระบบและมองเห็นบันทึกที่ไฟล์นี้แนบอยู่ ส่ง download=1 เพื่อบังคับให้ดาวน์โหลดแทนการแสดงในหน้า
+func DownloadAttachment(c *fiber.Ctx) error {
+	var attachment models.Attachment
+	if err := database.DB.First(&attachment, c.Params("id")).Error; err != nil {
+		return errLookup(err, "Attachment not found")
+	}
+
+	if !verifyAttachmentSignature(attachment.ID, c.Query("expires"), c.Query("signature")) {
+		user := currentUser(c)
+		if user == nil {
+			return errUnauthorized("Unauthorized")
+		}
+		visible, err := canViewAttachment(user, attachment)
+		if err != nil {
+			return errInternal(err, "Failed to check diary access")
+		}
+		if !visible {
+			return errForbidden("You cannot access this attachment")
+		}
+	}
+
+	reader, info, err := storage.Default().Open(attachmentKey(attachment))
+	if errors.Is(err, storage.ErrNotExist) {
+		return errNotFound("File not found").WithTH("ไม่พบไฟล์")
+	}
+	if err != nil {
+		return errInternal(err, "Cannot read file").WithTH("ไม่สามารถอ่านไฟล์ได้")
+	}
+
+	contentType := attachment.FileType
+	if contentType == "" {
+		contentType = info.ContentType
+	}
+	if contentType == "" {
+		contentType = mime.TypeByExtension(filepath.Ext(attachment.FileName))
+	}
+	if contentType == "" {
+		contentType = fiber.MIMEOctetStream
+	}
+
+	disposition := "attachment"
+	if inlineContentType(contentType) && !c.QueryBool("download") {
+		disposition = "inline"
+	}
+
+	c.Set(fiber.HeaderContentType, contentType)
+	if header := mime.FormatMediaType(disposition, map[string]string{"filename": attachment.FileName}); header != "" {
+		disposition = header
+	}
+	c.Set(fiber.HeaderContentDisposition, disposition)
+	c.Set(fiber.HeaderXContentTypeOptions, "nosniff")
+	c.Set(fiber.HeaderCacheControl, "private, no-store")
+	return c.SendStream(reader, int(info.Size))
+}
@@ -0,0 +1,100 @@
+package controllers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"gofiber-auth/database"
+	"gofiber-auth/models"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// attachmentURLSecret คือกุญแจสำหรับลงนาม URL ไฟล์แนบ ตั้งค่าผ่าน ATTACHMENT_URL_SECRET
+// หากไม่ได้ตั้งจะสร้างกุญแจแยกจาก CORS_ALLOW_SECRET ด้วย HMAC เพื่อไม่ใช้กุญแจเดียวกับที่ลงนาม JWT
+func attachmentURLSecret() []byte {
+	if secret := os.Getenv("ATTACHMENT_URL_SECRET"); secret != "" {
+		return []byte(secret)
+	}
+	base := os.Getenv("CORS_ALLOW_SECRET")
+	if base == "" {
+		return nil
+	}
+	mac := hmac.New(sha256.New, []byte(base))
+	mac.Write([]byte("attachment-url"))
+	return mac.Sum(nil)
+}
+
+// attachmentURLTTL คืออายุของ URL ที่ลงนาม ตั้งค่าผ่าน ATTACHMENT_URL_TTL
+func attachmentURLTTL() time.Duration {
+	return envDuration("ATTACHMENT_URL_TTL", 15*time.Minute)
+}
+
+func attachmentSignature(secret []byte, id uint, expires int64) string {
+	mac := hmac.New(sha256.New, secret)
+	fmt.Fprintf(mac, "attachment:%d:%d", id, expires)
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// signedAttachmentURL สร้าง URL ดาวน์โหลดที่ใช้ได้โดยไม่ต้องส่ง Authorization header จนถึงเวลาที่คืนค่า
+// หากไม่ได้ตั้งกุญแจจะคืนค่า URL ที่ต้องเข้าสู่ระบบแทน
+func signedAttachmentURL(id uint) (string, time.Time) {
+	path := fmt.Sprintf("/api/diary/attachment/%d/download", id)
+	expiresAt := time.Now().Add(attachmentURLTTL()).Truncate(time.Second)
+
+	secret := attachmentURLSecret()
+	if len(secret) == 0 {
+		return path, expiresAt
+	}
+	expires := expiresAt.Unix()
+	return fmt.Sprintf("%s?expires=%d&signature=%s", path, expires, attachmentSignature(secret, id, expires)), expiresAt
+}
+
+// verifyAttachmentSignature ตรวจลายเซ็นและเวลาหมดอายุของ URL ไฟล์แนบ
+func verifyAttachmentSignature(id uint, expiresParam, signature string) bool {
+	secret := attachmentURLSecret()
+	if len(secret) == 0 || expiresParam == "" || signature == "" {
+		return false
+	}
+	expires, err := strconv.ParseInt(expiresParam, 10, 64)
+	if err != nil || time.Now().Unix() > expires {
+		return false
+	}
+	expected := attachmentSignature(secret, id, expires)
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
+
+// attachAttachmentURLs เติม URL ที่ลงนามให้ไฟล์แนบก่อนส่ง response
+func attachAttachmentURLs(attachments []models.Attachment) {
+	for i := range attachments {
+		attachments[i].URL, _ = signedAttachmentURL(attachments[i].ID)
+	}
+}
+
+// canViewAttachment ไฟล์แนบมองเห็นได้เมื่อผู้ใช้มองเห็นบันทึกที่ไฟล์แนบอยู่
+func canViewAttachment(user *models.User, attachment models.Attachment) (bool, error) {
+	var diary models.Diary
+	if err := database.DB.Select("id", "student_id", "is_shared").First(&diary, attachment.DiaryID).Error; err != nil {
+		return false, err
+	}
+	return canViewDiary(user, diary)
+}
+
+// inlineContentType คือชนิดไฟล์ที่แสดงในเบราว์เซอร์ได้อย่างปลอดภัย ชนิดอื่นจะถูกส่งให้ดาวน์โหลด
+func inlineContentType(contentType string) bool {
+	contentType = strings.ToLower(contentType)
+	switch {
+	case strings.HasPrefix(contentType, "image/svg"):
+		return false
+	case strings.HasPrefix(contentType, "image/"),
+		strings.HasPrefix(contentType, "video/"),
+		strings.HasPrefix(contentType, "audio/"),
+		strings.HasPrefix(contentType, "application/pdf"),
+		strings.HasPrefix(contentType, "text/plain"):
+		return true
+	}
+	return false
+}
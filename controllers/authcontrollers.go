@@ -1,8 +1,10 @@
 package controllers
 
 import (
+	"errors"
 	"gofiber-auth/database"
 	"gofiber-auth/models"
+	"log"
 	"os"
 	"time"
 
@@ -11,34 +13,54 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
+// LoginData คือข้อมูลจากการเข้าสู่ระบบด้วย Microsoft ตัวตนของผู้ใช้มาจาก id_token เท่านั้น
 type LoginData struct {
-	Email string  `json:"email"`
-	Name  *string `json:"name"`
-	Image *string `json:"image"`
+	IDToken string  `json:"id_token" validate:"required"`
+	Name    *string `json:"name" validate:"omitempty,max=255"`
+	Image   *string `json:"image"`
+}
+
+type LoginRequest struct {
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required"`
 }
 
 var jwtSecret = []byte(os.Getenv("CORS_ALLOW_SECRET"))
 
 func HandleMicrosoftLogin(c *fiber.Ctx) error {
 	var data LoginData
-	if err := c.BodyParser(&data); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Cannot parse JSON",
-		})
+	if err := bindBody(c, &data); err != nil {
+		return err
+	}
+
+	claims, err := verifyMicrosoftIDToken(data.IDToken)
+	if errors.Is(err, errMicrosoftNotConfigured) {
+		return errInternal(err, "Microsoft login is not configured")
+	}
+	if err != nil {
+		log.Printf("[%s] Rejected Microsoft ID token: %v", requestID(c), err)
+		return errUnauthorized("Invalid Microsoft ID token")
 	}
 
-	if data.Email == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Email is required",
-		})
+	email := claims.Email
+	if email == "" {
+		email = claims.PreferredUsername
+	}
+	if email == "" {
+		return errUnauthorized("Microsoft ID token has no email")
 	}
 
 	var user models.User
-	result := database.DB.Where("email = ?", data.Email).First(&user)
-	if result.Error != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": "User not found",
-		})
+	if err := database.DB.Where("email = ?", email).First(&user).Error; err != nil {
+		return errLookup(err, "User not found")
+	}
+
+	if !user.Approved {
+		return errUnauthorized("Your account has not been approved yet")
+	}
+
+	if claims.Name != "" {
+		data.Name = &claims.Name
 	}
 
 	updated := false
@@ -54,68 +76,59 @@ func HandleMicrosoftLogin(c *fiber.Ctx) error {
 
 	if updated {
 		if err := database.DB.Save(&user).Error; err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to update user",
-			})
+			return errInternal(err, "Failed to update user")
 		}
 	}
 
-	return c.JSON(fiber.Map{
-		"message": "Login successful",
-		"role":    user.Role,
+	signedToken, err := issueToken(user)
+	if err != nil {
+		return errInternal(err, "Could not generate token")
+	}
+
+	return respond(c, fiber.StatusOK, "Login successful", fiber.Map{
+		"token": signedToken,
+		"role":  user.Role,
 	})
 }
 
 func LoginHandler(c *fiber.Ctx) error {
-	type LoginInput struct {
-		Email    string `json:"email"`
-		Password string `json:"password"`
+	var input LoginRequest
+	if err := bindBody(c, &input); err != nil {
+		return err
 	}
 
-	var input LoginInput
-	if err := c.BodyParser(&input); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid input",
-		})
+	var user models.User
+	if err := database.DB.Where("email = ? AND approved = ?", input.Email, true).First(&user).Error; err != nil {
+		return errUnauthorized("Your account has not been approved yet")
 	}
 
-	if input.Email == "" || input.Password == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Email and password are required",
-		})
+	if user.Password == nil {
+		return errUnauthorized("Incorrect password")
 	}
 
-	var user models.User
-	if err := database.DB.Where("email = ? AND approved = ?", input.Email, true).First(&user).Error; err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": "Your account has not been approved yet",
-		})
+	if err := bcrypt.CompareHashAndPassword([]byte(*user.Password), []byte(input.Password)); err != nil {
+		return errUnauthorized("Incorrect password")
 	}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(*user.Password), []byte(input.Password)); err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": "Incorrect password",
-		})
+	signedToken, err := issueToken(user)
+	if err != nil {
+		return errInternal(err, "Could not generate token")
 	}
 
+	return respond(c, fiber.StatusOK, "Login successful", fiber.Map{
+		"token": signedToken,
+		"role":  user.Role,
+	})
+}
+
+// issueToken สร้าง JWT อายุ 24 ชั่วโมงที่ AuthMiddleware ใช้ระบุตัวผู้ใช้
+func issueToken(user models.User) (string, error) {
 	claims := jwt.MapClaims{
 		"user_id": user.ID,
 		"email":   user.Email,
 		"role":    user.Role,
 		"exp":     time.Now().Add(time.Hour * 24).Unix(),
 	}
-
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	signedToken, err := token.SignedString(jwtSecret)
-	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Could not generate token",
-		})
-	}
-
-	return c.JSON(fiber.Map{
-		"message": "Login successful",
-		"token":   signedToken,
-		"role":    user.Role,
-	})
+	return token.SignedString(jwtSecret)
 }
@@ -5,157 +5,559 @@ import (
 	"fmt"
 	"gofiber-auth/database"
 	"gofiber-auth/models"
+	"log"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
 )
 
+// commentMaxDepth คือระดับลึกสุดของการตอบกลับ ตั้งค่าผ่าน COMMENT_MAX_DEPTH (ความคิดเห็นระดับบนสุดคือ 0)
+func commentMaxDepth() int {
+	return envInt("COMMENT_MAX_DEPTH", 3)
+}
+
+// commentEditWindow คือระยะเวลาหลังสร้างความคิดเห็นที่ผู้เขียนยังแก้ไขได้ ตั้งค่าผ่าน COMMENT_EDIT_WINDOW
+func commentEditWindow() time.Duration {
+	return envDuration("COMMENT_EDIT_WINDOW", 15*time.Minute)
+}
+
+// defaultCommentReplies คือจำนวนการตอบกลับที่แสดงในแต่ละระดับเมื่อไม่ได้ระบุ replies
+const defaultCommentReplies = 3
+
+type CreateCommentRequest struct {
+	DiaryID  uint   `json:"DiaryID" validate:"required"`
+	ParentID *uint  `json:"ParentID"`
+	Content  string `json:"Content" validate:"required,max=2000"`
+}
+
+type UpdateCommentRequest struct {
+	Content string `json:"Content" validate:"required,max=2000"`
+}
+
+type CommentTreeQuery struct {
+	DiaryID uint `query:"DiaryID" validate:"required"`
+	Page    int  `query:"page"`
+	Limit   int  `query:"limit"`
+	Replies int  `query:"replies" validate:"omitempty,gte=0,lte=50"`
+}
+
+type CommentRepliesQuery struct {
+	Page    int `query:"page"`
+	Limit   int `query:"limit"`
+	Replies int `query:"replies" validate:"omitempty,gte=0,lte=50"`
+}
+
+// CommentNode คือความคิดเห็นพร้อมการตอบกลับในเธรด
+// Replies มีไม่เกินจำนวนที่ขอ ส่วน ReplyCount คือจำนวนการตอบกลับโดยตรงทั้งหมด
+type CommentNode struct {
+	models.Comment
+	Edited     bool           `json:"edited"`
+	ReplyCount int64          `json:"reply_count"`
+	HasMore    bool           `json:"has_more_replies"`
+	Replies    []*CommentNode `json:"replies"`
+}
+
+// CreateNewComment สร้างความคิดเห็นในนามผู้ใช้ที่เข้าสู่ระบบ ผู้ใช้ต้องมองเห็นบันทึก และบันทึกต้องเปิดให้แสดงความคิดเห็น
 func CreateNewComment(c *fiber.Ctx) error {
-	var comment models.Comment
+	user := currentUser(c)
+	if user == nil {
+		return errUnauthorized("Unauthorized")
+	}
 
-	if err := c.BodyParser(&comment); err != nil {
-		return c.Status(400).JSON(fiber.Map{
-			"error":   "Cannot parse JSON",
-			"comment": err.Error(),
-		})
+	var req CreateCommentRequest
+	if err := bindBody(c, &req); err != nil {
+		return err
+	}
+	if err := checkProfanity("Content", req.Content); err != nil {
+		return err
 	}
 
-	if comment.DiaryID == 0 {
-		return c.Status(400).JSON(fiber.Map{
-			"error": "DiaryID is required",
-		})
+	var diary models.Diary
+	if err := database.DB.First(&diary, req.DiaryID).Error; err != nil {
+		return errLookup(err, "Diary not found")
 	}
-	if comment.AuthorID == 0 {
-		return c.Status(400).JSON(fiber.Map{
-			"error": "AuthorID is required",
-		})
+	visible, err := canViewDiary(user, diary)
+	if err != nil {
+		return errInternal(err, "Failed to check diary access")
 	}
-	if comment.Content == "" {
-		return c.Status(400).JSON(fiber.Map{
-			"error": "Content is required",
-		})
+	if !visible {
+		return errForbidden("You cannot comment on this diary")
+	}
+	if !diary.AllowComment {
+		return errForbidden("Comments are disabled for this diary").WithTH("บันทึกนี้ปิดการแสดงความคิดเห็น")
+	}
+
+	comment := models.Comment{
+		DiaryID:  req.DiaryID,
+		AuthorID: user.ID,
+		Content:  req.Content,
+	}
+
+	var parent models.Comment
+	if req.ParentID != nil {
+		if err := database.DB.First(&parent, *req.ParentID).Error; err != nil {
+			return errLookup(err, "Parent comment not found")
+		}
+		if parent.DiaryID != req.DiaryID {
+			return errBadRequest("Parent comment belongs to another diary").WithTH("ความคิดเห็นที่ตอบกลับอยู่ในบันทึกอื่น")
+		}
+		if parent.Depth+1 > commentMaxDepth() {
+			return errBadRequest("Maximum reply depth reached").WithTH("ไม่สามารถตอบกลับลึกกว่านี้ได้")
+		}
+		comment.ParentID = &parent.ID
+		comment.Depth = parent.Depth + 1
 	}
 
 	if err := database.DB.Create(&comment).Error; err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"error":   "Failed to create comment",
-			"details": err.Error(),
-		})
+		return errInternal(err, "Failed to create comment")
 	}
 
-	var diary models.Diary
-	if err := database.DB.First(&diary, comment.DiaryID).Error; err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"error":   "Failed to find diary",
-			"details": err.Error(),
-		})
+	var replyTo *models.Comment
+	if req.ParentID != nil {
+		replyTo = &parent
 	}
+	if err := notifyCommentCreated(diary, comment, replyTo); err != nil {
+		log.Printf("[%s] Failed to send notifications for comment %d: %v", requestID(c), comment.ID, err)
+	}
+	recordMentionsAsync(diary, &comment.ID, comment.AuthorID, comment.Content)
 
-	var advisors []models.StudentAdvisor
-	result := database.DB.Preload("Advisor").Where("student_id = ?", diary.StudentID).Find(&advisors)
-	if result.Error != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"error":   "Failed to find advisors",
-			"details": result.Error.Error(),
-		})
+	return respond(c, fiber.StatusCreated, "Created comment successfully", comment)
+}
+
+// notifyCommentCreated แจ้งผู้เกี่ยวข้องเมื่อมีความคิดเห็นใหม่ โดยไม่แจ้งผู้เขียนเอง และแจ้งแต่ละคนเพียงครั้งเดียว
+//   - เจ้าของความคิดเห็นที่ถูกตอบกลับ (parent ไม่เป็น nil)
+//   - นิสิตเจ้าของบันทึก
+//   - อาจารย์ที่ปรึกษาของนิสิต เมื่อนิสิตเป็นผู้แสดงความคิดเห็น
+//   - ผู้ที่เคยแสดงความคิดเห็นในบันทึกนี้
+func notifyCommentCreated(diary models.Diary, comment models.Comment, parent *models.Comment) error {
+	var author models.User
+	if err := database.DB.First(&author, comment.AuthorID).Error; err != nil {
+		return err
 	}
+	authorName := userDisplayName(author)
+	diaryDate := diary.DiaryDate.Format(dateLayout)
 
-	for _, sa := range advisors {
-		var student models.User
-		database.DB.First(&student, diary.StudentID)
+	data := map[string]interface{}{
+		"diary_date": diaryDate,
+		"student_id": diary.StudentID,
+		"comment_id": comment.ID,
+	}
+	if parent != nil {
+		data["parent_id"] = parent.ID
+	}
+	dataJSON, _ := json.Marshal(data)
 
-		notificationData := map[string]interface{}{
-			"diary_date": diary.DiaryDate.Format("2006-01-02"),
-			"student_id": diary.StudentID,
+	notified := map[uint]bool{comment.AuthorID: true}
+	notify := func(userID uint, notifType, title, message string) error {
+		if notified[userID] {
+			return nil
 		}
-		dataJSON, _ := json.Marshal(notificationData)
+		notified[userID] = true
+		return notifyUser(&models.Notification{
+			UserID:  userID,
+			DiaryID: &diary.ID,
+			Type:    notifType,
+			Title:   title,
+			Message: message,
+			Data:    dataJSON,
+		})
+	}
 
-		var studentName string
-		if student.Name != nil {
-			studentName = *student.Name
-		} else {
-			studentName = "ไม่ระบุชื่อ"
+	if parent != nil {
+		if err := notify(parent.AuthorID, "comment_reply", "มีการตอบกลับความคิดเห็นของคุณ",
+			fmt.Sprintf("%s ตอบกลับความคิดเห็นของคุณ", authorName)); err != nil {
+			return err
 		}
+	}
 
-		notif := models.Notification{
-			UserID:  sa.AdvisorID,
-			DiaryID: &diary.ID,
-			Type:    "comment",
-			Title:   "นิสิตมีความคิดเห็นใหม่",
-			Message: fmt.Sprintf("%s เพิ่มคอมเมนต์ใหม่", studentName),
-			Data:    dataJSON,
-			IsRead:  false,
+	if err := notify(diary.StudentID, "comment", "มีความคิดเห็นใหม่ในบันทึกของคุณ",
+		fmt.Sprintf("%s แสดงความคิดเห็นในบันทึกวันที่ %s", authorName, diaryDate)); err != nil {
+		return err
+	}
+
+	if comment.AuthorID == diary.StudentID {
+		var advisorIDs []uint
+		if err := database.DB.Model(&models.StudentAdvisor{}).
+			Where("student_id = ?", diary.StudentID).
+			Pluck("advisor_id", &advisorIDs).Error; err != nil {
+			return err
 		}
+		for _, advisorID := range advisorIDs {
+			if err := notify(advisorID, "comment", "นิสิตมีความคิดเห็นใหม่",
+				fmt.Sprintf("%s เพิ่มคอมเมนต์ใหม่", authorName)); err != nil {
+				return err
+			}
+		}
+	}
 
-		if err := database.DB.Create(&notif).Error; err != nil {
-			continue
+	var participantIDs []uint
+	if err := database.DB.Model(&models.Comment{}).
+		Where("diary_id = ?", diary.ID).
+		Distinct("author_id").
+		Pluck("author_id", &participantIDs).Error; err != nil {
+		return err
+	}
+	for _, participantID := range participantIDs {
+		if err := notify(participantID, "comment", "มีความคิดเห็นใหม่ในบทสนทนาที่คุณเข้าร่วม",
+			fmt.Sprintf("%s แสดงความคิดเห็นในบันทึกวันที่ %s", authorName, diaryDate)); err != nil {
+			return err
 		}
+	}
+
+	return nil
+}
+
+// pageParams คืนค่า page และ limit ที่ปรับให้อยู่ในช่วงที่ใช้ได้
+func pageParams(page, limit int) (int, int) {
+	if limit <= 0 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+	if page < 1 {
+		page = 1
+	}
+	return page, limit
+}
 
-		if conn, ok := advisorChannels[sa.AdvisorID]; ok {
-			select {
-			case conn.Ch <- notif:
-			default:
+// replyLimit คืนค่าจำนวนการตอบกลับที่แสดงในแต่ละระดับ หรือ defaultCommentReplies หากไม่ได้ระบุ
+func replyLimit(c *fiber.Ctx, replies int) int {
+	if c.Query("replies") == "" {
+		return defaultCommentReplies
+	}
+	return replies
+}
+
+// loadCommentTree โหลดความคิดเห็นทั้งหมดของบันทึกและจัดเป็นต้นไม้ คืนค่าความคิดเห็นระดับบนสุดและ node ตาม id
+// แต่ละ node มีการตอบกลับโดยตรงครบทุกรายการ ให้ใช้ trimReplies ก่อนส่งกลับ
+func loadCommentTree(diaryID uint) ([]*CommentNode, map[uint]*CommentNode, error) {
+	var comments []models.Comment
+	if err := database.DB.
+		Preload("Author").
+		Where("diary_id = ?", diaryID).
+		Order("created_at ASC, id ASC").
+		Find(&comments).Error; err != nil {
+		return nil, nil, err
+	}
+
+	nodes := make(map[uint]*CommentNode, len(comments))
+	for _, comment := range comments {
+		nodes[comment.ID] = &CommentNode{Comment: comment, Edited: comment.EditedAt != nil, Replies: []*CommentNode{}}
+	}
+
+	roots := []*CommentNode{}
+	for _, comment := range comments {
+		node := nodes[comment.ID]
+		if comment.ParentID != nil {
+			if parent, ok := nodes[*comment.ParentID]; ok {
+				parent.Replies = append(parent.Replies, node)
+				parent.ReplyCount++
+				continue
 			}
 		}
+		roots = append(roots, node)
 	}
 
-	return c.Status(201).JSON(fiber.Map{
-		"message": "Created comment successfully",
-		"data":    comment,
-	})
+	if err := attachCommentReactions(nodes); err != nil {
+		return nil, nil, err
+	}
+
+	return roots, nodes, nil
+}
+
+// trimReplies ตัดการตอบกลับของทุกระดับให้เหลือไม่เกิน limit รายการแรก
+func trimReplies(nodes []*CommentNode, limit int) {
+	for _, node := range nodes {
+		if len(node.Replies) > limit {
+			node.Replies = node.Replies[:limit]
+			node.HasMore = true
+		}
+		trimReplies(node.Replies, limit)
+	}
+}
+
+func paginateNodes(nodes []*CommentNode, page, limit int) []*CommentNode {
+	start := (page - 1) * limit
+	if start >= len(nodes) {
+		return []*CommentNode{}
+	}
+	end := start + limit
+	if end > len(nodes) {
+		end = len(nodes)
+	}
+	return nodes[start:end]
+}
+
+// requireDiaryVisible คืนค่าข้อผิดพลาดหากไม่พบบันทึกหรือผู้ใช้มองไม่เห็นบันทึก ใช้ก่อนส่งความคิดเห็นของบันทึก
+func requireDiaryVisible(user *models.User, diaryID uint) error {
+	var diary models.Diary
+	if err := database.DB.Select("id", "student_id", "is_shared").First(&diary, diaryID).Error; err != nil {
+		return errLookup(err, "Diary not found")
+	}
+	visible, err := canViewDiary(user, diary)
+	if err != nil {
+		return errInternal(err, "Failed to check diary access")
+	}
+	if !visible {
+		return errForbidden("You cannot view comments on this diary")
+	}
+	return nil
 }
 
+// GetCommentByDiaryId คืนค่าความคิดเห็นของบันทึกเป็นเธรด แบ่งหน้าตามความคิดเห็นระดับบนสุด
+// และแสดงการตอบกลับไม่เกิน replies รายการต่อระดับ ดูการตอบกลับที่เหลือได้ที่ GetCommentReplies
 func GetCommentByDiaryId(c *fiber.Ctx) error {
-	diaryId := c.Query("DiaryID")
-	if diaryId == "" {
-		return c.Status(400).JSON(fiber.Map{
-			"error": "DiaryID is required",
-		})
+	user := currentUser(c)
+	if user == nil {
+		return errUnauthorized("Unauthorized")
 	}
 
-	var comments []models.Comment
+	var query CommentTreeQuery
+	if err := bindQuery(c, &query); err != nil {
+		return err
+	}
+	if err := requireDiaryVisible(user, query.DiaryID); err != nil {
+		return err
+	}
 
-	result := database.DB.
-		Preload("Author").
-		Where("diary_id = ?", diaryId).
-		Find(&comments)
+	roots, nodes, err := loadCommentTree(query.DiaryID)
+	if err != nil {
+		return errInternal(err, "Failed to retrieve comments")
+	}
 
-	if result.Error != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"error": result.Error.Error(),
-		})
+	if len(nodes) == 0 {
+		return errNotFound("No comments found for this DiaryID")
+	}
+
+	maskHiddenComments(nodes, user)
+
+	page, limit := pageParams(query.Page, query.Limit)
+	threads := paginateNodes(roots, page, limit)
+	trimReplies(threads, replyLimit(c, query.Replies))
+
+	return respondMeta(c, fiber.StatusOK, "Comments retrieved successfully", threads, fiber.Map{
+		"count":          len(threads),
+		"total_threads":  len(roots),
+		"total_comments": len(nodes),
+		"page":           page,
+		"limit":          limit,
+		"max_depth":      commentMaxDepth(),
+	})
+}
+
+// GetCommentReplies คืนค่าการตอบกลับโดยตรงของความคิดเห็นแบบแบ่งหน้า
+func GetCommentReplies(c *fiber.Ctx) error {
+	user := currentUser(c)
+	if user == nil {
+		return errUnauthorized("Unauthorized")
+	}
+
+	var query CommentRepliesQuery
+	if err := bindQuery(c, &query); err != nil {
+		return err
+	}
+
+	var comment models.Comment
+	if err := database.DB.First(&comment, c.Params("id")).Error; err != nil {
+		return errLookup(err, "Comment not found")
+	}
+	if err := requireDiaryVisible(user, comment.DiaryID); err != nil {
+		return err
+	}
+
+	_, nodes, err := loadCommentTree(comment.DiaryID)
+	if err != nil {
+		return errInternal(err, "Failed to retrieve comments")
+	}
+	node, ok := nodes[comment.ID]
+	if !ok {
+		return errNotFound("Comment not found")
+	}
+
+	maskHiddenComments(nodes, user)
+
+	page, limit := pageParams(query.Page, query.Limit)
+	replies := paginateNodes(node.Replies, page, limit)
+	trimReplies(replies, replyLimit(c, query.Replies))
+
+	return respondMeta(c, fiber.StatusOK, "Replies retrieved successfully", replies, fiber.Map{
+		"count": len(replies),
+		"total": node.ReplyCount,
+		"page":  page,
+		"limit": limit,
+	})
+}
+
+// UpdateComment แก้ไขความคิดเห็นได้เฉพาะผู้เขียนภายใน commentEditWindow และเก็บเนื้อหาเดิมไว้ใน CommentRevision
+func UpdateComment(c *fiber.Ctx) error {
+	user := currentUser(c)
+	if user == nil {
+		return errUnauthorized("Unauthorized")
+	}
+
+	var comment models.Comment
+	if err := database.DB.First(&comment, c.Params("id")).Error; err != nil {
+		return errLookup(err, "Comment not found")
+	}
+
+	if comment.AuthorID != user.ID {
+		return errForbidden("Only the author can edit this comment")
+	}
+	// ความคิดเห็นที่ถูกซ่อนโดยการตรวจสอบแก้ไขไม่ได้ เพื่อไม่ให้เปลี่ยนเนื้อหาที่ถูกรายงานไว้
+	if comment.Hidden {
+		return errForbidden("This comment has been hidden by moderation").WithTH("ความคิดเห็นนี้ถูกซ่อนโดยผู้ดูแล จึงแก้ไขไม่ได้")
+	}
+	if time.Since(comment.CreatedAt) > commentEditWindow() {
+		return errForbidden("The edit window for this comment has passed").WithTH("เลยเวลาที่สามารถแก้ไขความคิดเห็นนี้แล้ว")
+	}
+
+	var req UpdateCommentRequest
+	if err := bindBody(c, &req); err != nil {
+		return err
+	}
+	if err := checkProfanity("Content", req.Content); err != nil {
+		return err
 	}
 
-	if len(comments) == 0 {
-		return c.Status(404).JSON(fiber.Map{
-			"error": "No comments found for this DiaryID",
+	if req.Content != comment.Content {
+		now := time.Now()
+		err := database.DB.Transaction(func(tx *gorm.DB) error {
+			revision := models.CommentRevision{
+				CommentID: comment.ID,
+				EditorID:  user.ID,
+				Content:   comment.Content,
+			}
+			if err := tx.Create(&revision).Error; err != nil {
+				return err
+			}
+			return tx.Model(&comment).Updates(map[string]interface{}{
+				"content":    req.Content,
+				"edited_at":  now,
+				"edit_count": gorm.Expr("edit_count + 1"),
+			}).Error
 		})
+		if err != nil {
+			return errInternal(err, "Failed to update comment")
+		}
+		comment.Content = req.Content
+		comment.EditedAt = &now
+		comment.EditCount++
+
+		var diary models.Diary
+		if err := database.DB.First(&diary, comment.DiaryID).Error; err == nil {
+			recordMentionsAsync(diary, &comment.ID, comment.AuthorID, comment.Content)
+		}
 	}
 
-	return c.JSON(fiber.Map{
-		"message": "Comments retrieved successfully",
-		"data":    comments,
+	return respond(c, fiber.StatusOK, "Updated comment successfully", CommentNode{
+		Comment: comment,
+		Edited:  comment.EditedAt != nil,
+		Replies: []*CommentNode{},
 	})
 }
 
+// GetCommentHistory คืนค่าเนื้อหาก่อนแก้ไขทั้งหมดของความคิดเห็น เรียงจากเก่าไปใหม่
+// สำหรับอาจารย์ที่ปรึกษาของนิสิตเจ้าของบันทึกและผู้ดูแลระบบ
+func GetCommentHistory(c *fiber.Ctx) error {
+	user := currentUser(c)
+	if user == nil {
+		return errUnauthorized("Unauthorized")
+	}
+
+	var comment models.Comment
+	if err := database.DB.First(&comment, c.Params("id")).Error; err != nil {
+		return errLookup(err, "Comment not found")
+	}
+
+	if user.Role != "admin" {
+		var count int64
+		if err := database.DB.Model(&models.StudentAdvisor{}).
+			Joins("JOIN diaries ON diaries.student_id = student_advisors.student_id").
+			Where("diaries.id = ? AND student_advisors.advisor_id = ?", comment.DiaryID, user.ID).
+			Count(&count).Error; err != nil {
+			return errInternal(err, "Failed to check advisor relationship")
+		}
+		if count == 0 {
+			return errForbidden("You are not an advisor of this student")
+		}
+	}
+
+	revisions := []models.CommentRevision{}
+	if err := database.DB.
+		Where("comment_id = ?", comment.ID).
+		Order("created_at ASC, id ASC").
+		Find(&revisions).Error; err != nil {
+		return errInternal(err, "Failed to retrieve comment history")
+	}
+
+	// ซ่อนเนื้อหาของความคิดเห็นที่ถูกซ่อนเช่นเดียวกับ maskHiddenComments ผู้ดูแลระบบเห็นเนื้อหาเดิม
+	if comment.Hidden && user.Role != "admin" {
+		comment.Content = ""
+		for i := range revisions {
+			revisions[i].Content = ""
+		}
+	}
+
+	return respondMeta(c, fiber.StatusOK, "Comment history retrieved successfully", revisions, fiber.Map{
+		"count":           len(revisions),
+		"current_content": comment.Content,
+		"edited_at":       comment.EditedAt,
+	})
+}
+
+// commentSubtreeIDs คืนค่า ID ของความคิดเห็นและการตอบกลับทุกระดับที่อยู่ใต้ความคิดเห็นนั้น
+func commentSubtreeIDs(id uint) ([]uint, error) {
+	ids := []uint{id}
+	level := []uint{id}
+	for len(level) > 0 {
+		var children []uint
+		if err := database.DB.Model(&models.Comment{}).Where("parent_id IN ?", level).Pluck("id", &children).Error; err != nil {
+			return nil, err
+		}
+		ids = append(ids, children...)
+		level = children
+	}
+	return ids, nil
+}
+
+// DeleteComment ลบความคิดเห็นได้เฉพาะผู้เขียน นิสิตเจ้าของบันทึก และผู้ดูแลระบบ
 func DeleteComment(c *fiber.Ctx) error {
+	user := currentUser(c)
+	if user == nil {
+		return errUnauthorized("Unauthorized")
+	}
+
 	id := c.Params("id")
 	var comment models.Comment
 
-	if result := database.DB.First(&comment, id); result.Error != nil {
-		return c.Status(404).JSON(fiber.Map{
-			"error": "Comment not found",
-		})
+	if err := database.DB.First(&comment, id).Error; err != nil {
+		return errLookup(err, "Comment not found")
+	}
+
+	allowed, err := canDeleteComment(user, comment)
+	if err != nil {
+		return errInternal(err, "Failed to check comment permissions")
+	}
+	if !allowed {
+		return errForbidden("You cannot delete this comment")
+	}
+
+	// การตอบกลับถูกลบตาม foreign key ของ Parent แต่ reaction อ้างถึงรายการแบบ polymorphic
+	// จึงไม่มี foreign key ให้ลบตาม ต้องลบเองให้ครบทั้งเธรดย่อย
+	ids, err := commentSubtreeIDs(comment.ID)
+	if err != nil {
+		return errInternal(err, "Failed to load comment replies")
+	}
+	if err := database.DB.Where("target_type = ? AND target_id IN ?", ReactionTargetComment, ids).
+		Delete(&models.Reaction{}).Error; err != nil {
+		return errInternal(err, "Failed to delete comment reactions")
 	}
 
 	if err := database.DB.Delete(&comment).Error; err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"error":   "Failed to delete comment",
-			"details": err.Error(),
-		})
+		return errInternal(err, "Failed to delete comment")
 	}
 
-	return c.Status(200).JSON(fiber.Map{
-		"ok":      true,
-		"message": "Deleted comment successfully",
-	})
+	return respond(c, fiber.StatusOK, "Deleted comment successfully", nil)
 }
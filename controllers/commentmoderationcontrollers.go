@@ -0,0 +1,252 @@
+package controllers
+
+import (
+	"gofiber-auth/database"
+	"gofiber-auth/models"
+	"gofiber-auth/profanity"
+	"gofiber-auth/validation"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type ReportCommentRequest struct {
+	Reason  string  `json:"reason" validate:"required,oneof=spam harassment inappropriate other"`
+	Details *string `json:"details" validate:"omitempty,max=1000"`
+}
+
+type CommentReportQuery struct {
+	Status string `query:"status" validate:"omitempty,oneof=open resolved dismissed"`
+	Page   int    `query:"page"`
+	Limit  int    `query:"limit"`
+}
+
+type UpdateCommentReportRequest struct {
+	Status string `json:"status" validate:"required,oneof=open resolved dismissed"`
+}
+
+// commentReportHideThreshold คือจำนวนรายงานที่ยังเปิดอยู่ก่อนซ่อนความคิดเห็นอัตโนมัติเพื่อรอตรวจสอบ
+// ตั้งค่าผ่าน COMMENT_REPORT_HIDE_THRESHOLD
+func commentReportHideThreshold() int {
+	return envInt("COMMENT_REPORT_HIDE_THRESHOLD", 3)
+}
+
+// checkProfanity คืนค่าข้อผิดพลาดของฟิลด์หากข้อความมีถ้อยคำไม่สุภาพ
+func checkProfanity(field, text string) error {
+	if len(profanity.Find(text)) == 0 {
+		return nil
+	}
+	return errValidation(validation.Errors{{
+		Field:     field,
+		Rule:      "profanity",
+		MessageEN: "contains inappropriate language",
+		MessageTH: "มีถ้อยคำที่ไม่สุภาพ",
+	}})
+}
+
+// maskHiddenComments ซ่อนเนื้อหาของความคิดเห็นที่ถูกซ่อน โดยคง node ไว้เพื่อไม่ให้เธรดขาดตอน
+// ผู้ดูแลระบบเห็นเนื้อหาเดิม
+func maskHiddenComments(nodes map[uint]*CommentNode, viewer *models.User) {
+	if viewer != nil && viewer.Role == "admin" {
+		return
+	}
+	for _, node := range nodes {
+		if node.Hidden {
+			node.Content = ""
+		}
+	}
+}
+
+// canDeleteComment อนุญาตให้ผู้เขียน นิสิตเจ้าของบันทึก และผู้ดูแลระบบลบความคิดเห็น
+func canDeleteComment(user *models.User, comment models.Comment) (bool, error) {
+	if user.Role == "admin" || user.ID == comment.AuthorID {
+		return true, nil
+	}
+	var diary models.Diary
+	if err := database.DB.Select("id", "student_id").First(&diary, comment.DiaryID).Error; err != nil {
+		return false, err
+	}
+	return diary.StudentID == user.ID, nil
+}
+
+func setCommentHidden(comment *models.Comment, hidden bool, by *uint) error {
+	updates := map[string]interface{}{"hidden": hidden, "hidden_at": nil, "hidden_by": nil}
+	if hidden {
+		updates["hidden_at"] = time.Now()
+		updates["hidden_by"] = by
+	}
+	return database.DB.Model(comment).Updates(updates).Error
+}
+
+// ReportComment รายงานความคิดเห็นที่ไม่เหมาะสม เมื่อรายงานที่เปิดอยู่ถึงเกณฑ์ ความคิดเห็นจะถูกซ่อนจนกว่าผู้ดูแลระบบจะตรวจสอบ
+func ReportComment(c *fiber.Ctx) error {
+	user := currentUser(c)
+	if user == nil {
+		return errUnauthorized("Unauthorized")
+	}
+
+	var comment models.Comment
+	if err := database.DB.First(&comment, c.Params("id")).Error; err != nil {
+		return errLookup(err, "Comment not found")
+	}
+	if comment.AuthorID == user.ID {
+		return errBadRequest("You cannot report your own comment").WithTH("ไม่สามารถรายงานความคิดเห็นของตนเองได้")
+	}
+
+	var diary models.Diary
+	if err := database.DB.Select("id", "student_id", "is_shared").First(&diary, comment.DiaryID).Error; err != nil {
+		return errLookup(err, "Diary not found")
+	}
+	visible, err := canViewDiary(user, diary)
+	if err != nil {
+		return errInternal(err, "Failed to check diary access")
+	}
+	if !visible {
+		return errForbidden("You cannot report this comment")
+	}
+
+	var req ReportCommentRequest
+	if err := bindBody(c, &req); err != nil {
+		return err
+	}
+
+	var existing int64
+	if err := database.DB.Model(&models.CommentReport{}).
+		Where("comment_id = ? AND reporter_id = ?", comment.ID, user.ID).
+		Count(&existing).Error; err != nil {
+		return errInternal(err, "Failed to check existing reports")
+	}
+	if existing > 0 {
+		return errConflict("You have already reported this comment").WithTH("คุณรายงานความคิดเห็นนี้แล้ว")
+	}
+
+	report := models.CommentReport{
+		CommentID:  comment.ID,
+		ReporterID: user.ID,
+		Reason:     req.Reason,
+		Details:    req.Details,
+		Status:     "open",
+	}
+	if err := database.DB.Create(&report).Error; err != nil {
+		return errInternal(err, "Failed to report comment")
+	}
+
+	if !comment.Hidden {
+		var open int64
+		if err := database.DB.Model(&models.CommentReport{}).
+			Where("comment_id = ? AND status = ?", comment.ID, "open").
+			Count(&open).Error; err != nil {
+			return errInternal(err, "Failed to count reports")
+		}
+		if open >= int64(commentReportHideThreshold()) {
+			if err := setCommentHidden(&comment, true, nil); err != nil {
+				return errInternal(err, "Failed to hide comment")
+			}
+		}
+	}
+
+	return respond(c, fiber.StatusCreated, "Comment reported successfully", report)
+}
+
+// GetCommentReports คืนค่าคิวรายงานความคิดเห็นสำหรับผู้ดูแลระบบ ค่าเริ่มต้นคือรายงานที่ยังเปิดอยู่
+func GetCommentReports(c *fiber.Ctx) error {
+	var query CommentReportQuery
+	if err := bindQuery(c, &query); err != nil {
+		return err
+	}
+	if query.Status == "" {
+		query.Status = "open"
+	}
+	page, limit := pageParams(query.Page, query.Limit)
+
+	dbQuery := database.DB.Model(&models.CommentReport{}).Where("status = ?", query.Status)
+
+	var total int64
+	if err := dbQuery.Count(&total).Error; err != nil {
+		return errInternal(err, "Failed to count comment reports")
+	}
+
+	reports := []models.CommentReport{}
+	if err := dbQuery.
+		Preload("Comment.Author").
+		Preload("Reporter").
+		Order("created_at ASC").
+		Offset((page - 1) * limit).
+		Limit(limit).
+		Find(&reports).Error; err != nil {
+		return errInternal(err, "Failed to query comment reports")
+	}
+
+	return respondMeta(c, fiber.StatusOK, "Comment reports retrieved successfully", reports, fiber.Map{
+		"count":  len(reports),
+		"total":  total,
+		"page":   page,
+		"limit":  limit,
+		"status": query.Status,
+	})
+}
+
+func UpdateCommentReport(c *fiber.Ctx) error {
+	admin := currentUser(c)
+	if admin == nil {
+		return errUnauthorized("Unauthorized")
+	}
+
+	var report models.CommentReport
+	if err := database.DB.First(&report, c.Params("id")).Error; err != nil {
+		return errLookup(err, "Comment report not found")
+	}
+
+	var req UpdateCommentReportRequest
+	if err := bindBody(c, &req); err != nil {
+		return err
+	}
+
+	updates := map[string]interface{}{"status": req.Status, "reviewed_by": admin.ID, "reviewed_at": time.Now()}
+	if req.Status == "open" {
+		updates["reviewed_by"] = nil
+		updates["reviewed_at"] = nil
+	}
+	if err := database.DB.Model(&report).Updates(updates).Error; err != nil {
+		return errInternal(err, "Failed to update comment report")
+	}
+
+	if err := database.DB.Preload("Comment").Preload("Reporter").First(&report, report.ID).Error; err != nil {
+		return errInternal(err, "Failed to reload comment report")
+	}
+
+	return respond(c, fiber.StatusOK, "Comment report updated successfully", report)
+}
+
+func HideComment(c *fiber.Ctx) error {
+	return updateCommentVisibility(c, true)
+}
+
+func UnhideComment(c *fiber.Ctx) error {
+	return updateCommentVisibility(c, false)
+}
+
+func updateCommentVisibility(c *fiber.Ctx, hidden bool) error {
+	admin := currentUser(c)
+	if admin == nil {
+		return errUnauthorized("Unauthorized")
+	}
+
+	var comment models.Comment
+	if err := database.DB.First(&comment, c.Params("id")).Error; err != nil {
+		return errLookup(err, "Comment not found")
+	}
+
+	if err := setCommentHidden(&comment, hidden, &admin.ID); err != nil {
+		return errInternal(err, "Failed to update comment visibility")
+	}
+	if err := database.DB.First(&comment, comment.ID).Error; err != nil {
+		return errInternal(err, "Failed to reload comment")
+	}
+
+	message := "Comment unhidden successfully"
+	if hidden {
+		message = "Comment hidden successfully"
+	}
+	return respond(c, fiber.StatusOK, message, comment)
+}
@@ -0,0 +1,201 @@
+package controllers
+
+import (
+	"gofiber-auth/database"
+	"gofiber-auth/models"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// dashboardRecentDays คือช่วงวันย้อนหลังที่ dashboard ใช้นับว่าเป็นบันทึกล่าสุด
+const dashboardRecentDays = 7
+
+// dashboardListLimit คือจำนวนรายการสูงสุดในแต่ละรายการของ dashboard
+const dashboardListLimit = 10
+
+type NegativeMoodStudent struct {
+	StudentID       uint    `json:"student_id"`
+	Name            *string `json:"name"`
+	NegativeEntries int64   `json:"negative_entries"`
+	LastNegative    string  `json:"last_negative_date"`
+	LowestScore     int     `json:"lowest_score"`
+}
+
+type UncommentedDiary struct {
+	DiaryID   uint    `json:"diary_id"`
+	StudentID uint    `json:"student_id"`
+	Name      *string `json:"name"`
+	Status    string  `json:"status"`
+	DiaryDate string  `json:"diary_date"`
+}
+
+type GroupActivity struct {
+	GroupID       uint     `json:"group_id"`
+	Name          string   `json:"name"`
+	Members       int64    `json:"members"`
+	ActiveMembers int64    `json:"active_members"`
+	Entries       int64    `json:"entries"`
+	AverageScore  *float64 `json:"average_score"`
+}
+
+type AdvisorDashboard struct {
+	SupervisedStudents      int64                 `json:"supervised_students"`
+	EntriesToday            int64                 `json:"entries_today"`
+	EntriesThisWeek         int64                 `json:"entries_this_week"`
+	NegativeMoodStudents    []NegativeMoodStudent `json:"negative_mood_students"`
+	UnreadNotifications     int64                 `json:"unread_notifications"`
+	UnreadHighPriority      int64                 `json:"unread_high_priority"`
+	PendingRequests         int64                 `json:"pending_requests"`
+	UncommentedDiaries      []UncommentedDiary    `json:"uncommented_diaries"`
+	UncommentedDiariesTotal int64                 `json:"uncommented_diaries_total"`
+	Groups                  []GroupActivity       `json:"groups"`
+	// ResponseTimes คือเวลาตอบกลับของอาจารย์ในช่วง responseTimeWindowDays วันล่าสุด
+	ResponseTimes ResponseTimeStats `json:"response_times"`
+}
+
+func GetAdvisorDashboard(c *fiber.Ctx) error {
+	user := currentUser(c)
+	if user == nil {
+		return errUnauthorized("Unauthorized")
+	}
+
+	var query ScopedAdvisorQuery
+	if err := bindQuery(c, &query); err != nil {
+		return err
+	}
+	advisorID, err := scopedAdvisorID(user, query.AdvisorID)
+	if err != nil {
+		return err
+	}
+
+	var advisor models.User
+	if err := database.DB.Where("id = ? AND role = ?", advisorID, "advisor").First(&advisor).Error; err != nil {
+		return errLookup(err, "Advisor not found")
+	}
+
+	setting, err := moodAlertSettingFor(advisorID)
+	if err != nil {
+		return errInternal(err, "Failed to load alert settings")
+	}
+
+	loc, _ := time.LoadLocation("Asia/Bangkok")
+	now := time.Now().In(loc)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	weekStart := periodStart(today, "week")
+	recentStart := today.AddDate(0, 0, -(dashboardRecentDays - 1))
+
+	dashboard := AdvisorDashboard{
+		NegativeMoodStudents: []NegativeMoodStudent{},
+		UncommentedDiaries:   []UncommentedDiary{},
+		Groups:               []GroupActivity{},
+	}
+
+	if err := database.DB.Model(&models.StudentAdvisor{}).
+		Where("advisor_id = ?", advisorID).
+		Count(&dashboard.SupervisedStudents).Error; err != nil {
+		return errInternal(err, "Failed to count students")
+	}
+
+	// บันทึกของนิสิตในการดูแล ใช้ Session เพื่อใช้ฐาน query ซ้ำได้
+	supervised := database.DB.Model(&models.Diary{}).
+		Joins("JOIN student_advisors ON student_advisors.student_id = diaries.student_id").
+		Where("student_advisors.advisor_id = ?", advisorID).
+		Session(&gorm.Session{})
+
+	var entryCounts struct {
+		Today    int64
+		ThisWeek int64
+	}
+	if err := supervised.
+		Where("diaries.diary_date >= ?", weekStart.Format(dateLayout)).
+		Select("COALESCE(SUM(CASE WHEN diaries.diary_date = ? THEN 1 ELSE 0 END), 0) AS today, COUNT(*) AS this_week", today.Format(dateLayout)).
+		Scan(&entryCounts).Error; err != nil {
+		return errInternal(err, "Failed to count entries")
+	}
+	dashboard.EntriesToday = entryCounts.Today
+	dashboard.EntriesThisWeek = entryCounts.ThisWeek
+
+	if err := supervised.
+		Joins("JOIN mood_scales ON mood_scales.mood_key = diaries.status").
+		Joins("JOIN users ON users.id = diaries.student_id").
+		Where("diaries.diary_date >= ? AND mood_scales.score <= ?", recentStart.Format(dateLayout), setting.NegativeScoreMax).
+		Select("diaries.student_id AS student_id, users.name AS name, COUNT(*) AS negative_entries, " +
+			"DATE_FORMAT(MAX(diaries.diary_date), '%Y-%m-%d') AS last_negative, MIN(mood_scales.score) AS lowest_score").
+		Group("diaries.student_id, users.name").
+		Order("negative_entries DESC, last_negative DESC").
+		Scan(&dashboard.NegativeMoodStudents).Error; err != nil {
+		return errInternal(err, "Failed to query negative moods")
+	}
+
+	var notificationCounts struct {
+		Unread       int64
+		HighPriority int64
+	}
+	if err := database.DB.Model(&models.Notification{}).
+		Where("user_id = ? AND is_read = ?", advisorID, false).
+		Select("COUNT(*) AS unread, COALESCE(SUM(CASE WHEN priority IN ? THEN 1 ELSE 0 END), 0) AS high_priority", []string{"high", "urgent"}).
+		Scan(&notificationCounts).Error; err != nil {
+		return errInternal(err, "Failed to count notifications")
+	}
+	dashboard.UnreadNotifications = notificationCounts.Unread
+	dashboard.UnreadHighPriority = notificationCounts.HighPriority
+
+	if err := database.DB.Model(&models.AdvisorNotification{}).
+		Where("advisor_id = ? AND is_read = ?", advisorID, false).
+		Count(&dashboard.PendingRequests).Error; err != nil {
+		return errInternal(err, "Failed to count advisor requests")
+	}
+
+	// บันทึกล่าสุดที่อาจารย์มองเห็นได้และยังไม่ได้แสดงความคิดเห็น
+	uncommented := supervised.
+		Where("diaries.diary_date >= ? AND diaries.is_shared <> ?", recentStart.Format(dateLayout), "private").
+		Where("NOT EXISTS (SELECT 1 FROM comments WHERE comments.diary_id = diaries.id AND comments.author_id = ?)", advisorID).
+		Session(&gorm.Session{})
+	if err := uncommented.Count(&dashboard.UncommentedDiariesTotal).Error; err != nil {
+		return errInternal(err, "Failed to count uncommented diaries")
+	}
+	if err := uncommented.
+		Joins("JOIN users ON users.id = diaries.student_id").
+		Select("diaries.id AS diary_id, diaries.student_id AS student_id, users.name AS name, " +
+			"diaries.status AS status, DATE_FORMAT(diaries.diary_date, '%Y-%m-%d') AS diary_date").
+		Order("diaries.diary_date DESC, diaries.id DESC").
+		Limit(dashboardListLimit).
+		Scan(&dashboard.UncommentedDiaries).Error; err != nil {
+		return errInternal(err, "Failed to query uncommented diaries")
+	}
+
+	// `groups` เป็นคำสงวนของ MySQL 8 จึงต้องครอบด้วย backtick
+	if err := database.DB.Table("`groups`").
+		Joins("LEFT JOIN student_groups ON student_groups.group_id = `groups`.id").
+		Joins("LEFT JOIN diaries ON diaries.student_id = student_groups.student_id AND diaries.diary_date >= ?", recentStart.Format(dateLayout)).
+		Joins("LEFT JOIN mood_scales ON mood_scales.mood_key = diaries.status").
+		Where("`groups`.advisor_id = ?", advisorID).
+		Select("`groups`.id AS group_id, `groups`.name AS name, " +
+			"COUNT(DISTINCT student_groups.student_id) AS members, " +
+			"COUNT(DISTINCT diaries.student_id) AS active_members, " +
+			"COUNT(diaries.id) AS entries, AVG(mood_scales.score) AS average_score").
+		Group("`groups`.id, `groups`.name").
+		Order("`groups`.name ASC").
+		Scan(&dashboard.Groups).Error; err != nil {
+		return errInternal(err, "Failed to query group activity")
+	}
+
+	responseStart := today.AddDate(0, 0, -(responseTimeWindowDays - 1))
+	responseTimes, err := responseTimeStats(advisorID, responseStart.Format(dateLayout), today.Format(dateLayout))
+	if err != nil {
+		return errInternal(err, "Failed to compute response times")
+	}
+	dashboard.ResponseTimes = ResponseTimeStats{AdvisorID: advisorID, Name: advisor.Name}
+	if len(responseTimes) > 0 {
+		dashboard.ResponseTimes = responseTimes[0]
+	}
+
+	return respondMeta(c, fiber.StatusOK, "Dashboard retrieved successfully", dashboard, fiber.Map{
+		"today":         today.Format(dateLayout),
+		"week_start":    weekStart.Format(dateLayout),
+		"recent_from":   recentStart.Format(dateLayout),
+		"response_from": responseStart.Format(dateLayout),
+	})
+}
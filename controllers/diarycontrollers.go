@@ -5,19 +5,175 @@ import (
 	"fmt"
 	"gofiber-auth/database"
 	"gofiber-auth/models"
+	"log"
+	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
 )
 
+// diaryPatchFields คือฟิลด์ของ Diary ที่แก้ไขได้ผ่าน PATCH แยกตามบทบาทของผู้เรียก
+// "owner" คือนิสิตเจ้าของบันทึก
+var diaryPatchFields = []patchField{
+	{Column: "content_html", Aliases: []string{"ContentHTML"}, Roles: []string{"owner"}, Decode: decodePatchString, Rules: "required"},
+	{Column: "content_delta", Aliases: []string{"ContentDelta"}, Roles: []string{"owner"}, Nullable: true, Decode: decodePatchJSON},
+	{Column: "is_shared", Aliases: []string{"IsShared"}, Roles: []string{"owner"}, Decode: decodePatchString, Rules: "oneof=everyone advisor private"},
+	{Column: "allow_comment", Aliases: []string{"AllowComment"}, Roles: []string{"owner", "admin"}, Decode: decodePatchBool},
+	{Column: "status", Aliases: []string{"Status"}, Roles: []string{"owner"}, Decode: decodePatchString, Rules: "mood"},
+}
+
+type DiaryByDateQuery struct {
+	DiaryDate string `query:"DiaryDate" validate:"required,date"`
+	StudentID uint   `query:"StudentID" validate:"required"`
+}
+
+type CreateDiaryRequest struct {
+	ContentHTML  string         `json:"ContentHTML" validate:"required"`
+	ContentDelta datatypes.JSON `json:"ContentDelta"`
+	IsShared     string         `json:"IsShared" validate:"omitempty,oneof=everyone advisor private"`
+	AllowComment *bool          `json:"AllowComment"`
+	Status       string         `json:"Status" validate:"omitempty,mood"`
+	DiaryDate    time.Time      `json:"DiaryDate"`
+}
+
+type UpdateDiaryRequest struct {
+	ContentHTML  string         `json:"ContentHTML" validate:"required"`
+	ContentDelta datatypes.JSON `json:"ContentDelta"`
+	IsShared     string         `json:"IsShared" validate:"omitempty,oneof=everyone advisor private"`
+	AllowComment *bool          `json:"AllowComment"`
+	Status       string         `json:"Status" validate:"omitempty,mood"`
+}
+
+// diaryETag คืนค่า ETag ของบันทึกจาก ID และ Version ปัจจุบัน
+func diaryETag(diary models.Diary) string {
+	return fmt.Sprintf(`"%d-%d"`, diary.ID, diary.Version)
+}
+
+// ifMatchSatisfied ตรวจสอบ header If-Match ว่าตรงกับ ETag ของบันทึกหรือไม่
+// ไม่รับ "*" เพราะบันทึกมีอยู่เสมอ ซึ่งจะทำให้ข้ามการตรวจการเขียนทับได้
+func ifMatchSatisfied(header string, diary models.Diary) bool {
+	current := diaryETag(diary)
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		tag = strings.TrimPrefix(tag, "W/")
+		if tag == current {
+			return true
+		}
+	}
+	return false
+}
+
+// requireIfMatch คืนค่าข้อผิดพลาดหาก If-Match ไม่มีหรือไม่ตรงกับเวอร์ชันปัจจุบัน
+func requireIfMatch(c *fiber.Ctx, diary models.Diary) error {
+	header := c.Get(fiber.HeaderIfMatch)
+	if header == "" {
+		return &APIError{
+			Status:  fiber.StatusPreconditionRequired,
+			Code:    CodePreconditionRequired,
+			Message: "If-Match header is required",
+		}
+	}
+
+	if !ifMatchSatisfied(header, diary) {
+		return diaryConflict(c, diary.ID)
+	}
+
+	return nil
+}
+
+// diaryConflict คืนค่า 412 พร้อมข้อมูลบันทึกเวอร์ชันล่าสุดบนเซิร์ฟเวอร์ให้ client นำไป merge
+func diaryConflict(c *fiber.Ctx, id uint) error {
+	var current models.Diary
+	if err := database.DB.Preload("Attachments").First(&current, id).Error; err != nil {
+		return errLookup(err, "Diary not found")
+	}
+
+	attachAttachmentURLs(current.Attachments)
+	c.Set(fiber.HeaderETag, diaryETag(current))
+	return &APIError{
+		Status:  fiber.StatusPreconditionFailed,
+		Code:    CodePreconditionFailed,
+		Message: "Diary has been modified by another request",
+		Details: fiber.Map{"current": current},
+	}
+}
+
+// updateDiaryVersioned อัปเดตบันทึกเฉพาะเมื่อ version ยังตรงกับที่อ่านมา และเพิ่ม version ขึ้นหนึ่ง
+func updateDiaryVersioned(diary *models.Diary, updates map[string]interface{}) (bool, error) {
+	updates["version"] = gorm.Expr("version + 1")
+
+	result := database.DB.Model(&models.Diary{}).
+		Where("id = ? AND version = ?", diary.ID, diary.Version).
+		Updates(updates)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return false, nil
+	}
+
+	if err := database.DB.First(diary, diary.ID).Error; err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// decorateDiaries เติมข้อมูลที่ไม่ได้เก็บในตาราง diaries ได้แก่ URL ไฟล์แนบ จำนวน reaction และผู้ที่อ่านแล้ว
+func decorateDiaries(diaries []models.Diary) error {
+	for i := range diaries {
+		attachAttachmentURLs(diaries[i].Attachments)
+	}
+	if err := attachDiaryReactions(diaries); err != nil {
+		return err
+	}
+	return attachDiaryReads(diaries)
+}
+
+func GetDiaryById(c *fiber.Ctx) error {
+	user := currentUser(c)
+	if user == nil {
+		return errUnauthorized("Unauthorized")
+	}
+
+	id := c.Params("id")
+	var diary models.Diary
+
+	if err := database.DB.
+		Preload("Student").
+		Preload("Attachments").
+		First(&diary, id).Error; err != nil {
+		return errLookup(err, "Diary not found")
+	}
+
+	visible, err := canViewDiary(user, diary)
+	if err != nil {
+		return errInternal(err, "Failed to check diary access")
+	}
+	if !visible {
+		return errForbidden("You cannot view this diary")
+	}
+
+	diaries := []models.Diary{diary}
+	if err := decorateDiaries(diaries); err != nil {
+		return errInternal(err, "Failed to load diary details")
+	}
+	diary = diaries[0]
+
+	c.Set(fiber.HeaderETag, diaryETag(diary))
+	return respond(c, fiber.StatusOK, "Diary retrieved successfully", diary)
+}
+
 func GetDiaryByDate(c *fiber.Ctx) error {
-	DiaryDate := c.Query("DiaryDate")
-	studentID := c.Query("StudentID")
+	user := currentUser(c)
+	if user == nil {
+		return errUnauthorized("Unauthorized")
+	}
 
-	if DiaryDate == "" || studentID == "" {
-		return c.Status(400).JSON(fiber.Map{
-			"error": "DiaryDate and StudentID are required",
-		})
+	var query DiaryByDateQuery
+	if err := bindQuery(c, &query); err != nil {
+		return err
 	}
 
 	var diaries []models.Diary
@@ -25,47 +181,76 @@ func GetDiaryByDate(c *fiber.Ctx) error {
 	result := database.DB.
 		Preload("Student").
 		Preload("Attachments").
-		Where("diary_date = ? AND student_id = ?", DiaryDate, studentID).
+		Where("diary_date = ? AND student_id = ?", query.DiaryDate, query.StudentID).
 		Find(&diaries)
 
 	if result.Error != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"error": result.Error.Error(),
-		})
+		return errInternal(result.Error, "Failed to retrieve diaries")
 	}
 
 	if len(diaries) == 0 {
-		return c.Status(404).JSON(fiber.Map{
-			"error": "No diary entries found for this date and student",
-		})
+		return errNotFound("No diary entries found for this date and student")
 	}
 
-	return c.JSON(fiber.Map{
-		"message": "Diaries retrieved successfully",
-		"data":    diaries,
-	})
+	// ตัดบันทึกที่ผู้เรียกมองไม่เห็นออกก่อนเติม URL ไฟล์แนบ reaction และผู้ที่อ่านแล้ว
+	visible := diaries[:0]
+	for _, diary := range diaries {
+		ok, err := canViewDiary(user, diary)
+		if err != nil {
+			return errInternal(err, "Failed to check diary access")
+		}
+		if ok {
+			visible = append(visible, diary)
+		}
+	}
+	if len(visible) == 0 {
+		return errForbidden("You cannot view these diaries")
+	}
+	diaries = visible
+
+	// อาจารย์ที่เปิดอ่านบันทึกที่ผ่านการตรวจสิทธิ์ข้างต้นถือว่าอ่านแล้ว บันทึกส่วนตัวไม่บันทึกการอ่าน
+	if user.Role == "advisor" {
+		for _, diary := range diaries {
+			if diary.IsShared == "private" {
+				continue
+			}
+			if err := markDiaryRead(diary, user.ID); err != nil {
+				log.Printf("[%s] Failed to mark diary %d as read: %v", requestID(c), diary.ID, err)
+			}
+		}
+	}
+
+	if err := decorateDiaries(diaries); err != nil {
+		return errInternal(err, "Failed to load diary details")
+	}
+
+	if len(diaries) == 1 {
+		c.Set(fiber.HeaderETag, diaryETag(diaries[0]))
+	}
+
+	return respond(c, fiber.StatusOK, "Diaries retrieved successfully", diaries)
 }
 
 func CreateNewDiary(c *fiber.Ctx) error {
-	var diary models.Diary
-
-	if err := c.BodyParser(&diary); err != nil {
-		return c.Status(400).JSON(fiber.Map{
-			"error":   "Cannot parse JSON",
-			"details": err.Error(),
-		})
+	user := currentUser(c)
+	if user == nil {
+		return errUnauthorized("Unauthorized")
 	}
 
-	if diary.StudentID == 0 {
-		return c.Status(400).JSON(fiber.Map{
-			"error": "StudentID is required",
-		})
+	var req CreateDiaryRequest
+	if err := bindBody(c, &req); err != nil {
+		return err
 	}
 
-	if diary.ContentHTML == "" {
-		return c.Status(400).JSON(fiber.Map{
-			"error": "ContentHTML is required",
-		})
+	// เจ้าของบันทึกคือผู้ใช้ที่เข้าสู่ระบบ ไม่รับ StudentID จาก body
+	diary := models.Diary{
+		StudentID:    user.ID,
+		ContentHTML:  req.ContentHTML,
+		ContentDelta: req.ContentDelta,
+		IsShared:     req.IsShared,
+		AllowComment: req.AllowComment == nil || *req.AllowComment,
+		Status:       req.Status,
+		DiaryDate:    req.DiaryDate,
 	}
 
 	if diary.IsShared == "" {
@@ -73,7 +258,7 @@ func CreateNewDiary(c *fiber.Ctx) error {
 	}
 
 	if diary.Status == "" {
-		diary.Status = "neutral"
+		diary.Status = defaultMoodKey()
 	}
 
 	if diary.DiaryDate.IsZero() {
@@ -82,20 +267,14 @@ func CreateNewDiary(c *fiber.Ctx) error {
 	}
 
 	if err := database.DB.Create(&diary).Error; err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"error":   "Failed to create diary",
-			"details": err.Error(),
-		})
+		return errInternal(err, "Failed to create diary")
 	}
 
 	var advisors []models.StudentAdvisor
 	result := database.DB.Preload("Advisor").Where("student_id = ?", diary.StudentID).Find(&advisors)
 
 	if result.Error != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"error":   "Failed to find advisors",
-			"details": result.Error.Error(),
-		})
+		return errInternal(result.Error, "Failed to find advisors")
 	}
 
 	for _, sa := range advisors {
@@ -141,101 +320,154 @@ func CreateNewDiary(c *fiber.Ctx) error {
 		}
 	}
 
-	return c.Status(201).JSON(fiber.Map{
-		"message": "Created diary successfully",
-		"data":    diary,
-	})
+	evaluateMoodAlertsAsync(diary.StudentID)
+	onDiaryContentSaved(diary)
+
+	c.Set(fiber.HeaderETag, diaryETag(diary))
+	return respond(c, fiber.StatusCreated, "Created diary successfully", diary)
 }
 
 func UpdateDiary(c *fiber.Ctx) error {
+	user := currentUser(c)
+	if user == nil {
+		return errUnauthorized("Unauthorized")
+	}
+
 	id := c.Params("id")
 	var diary models.Diary
 
-	if result := database.DB.First(&diary, id); result.Error != nil {
-		return c.Status(404).JSON(fiber.Map{
-			"error": "Diary not found",
-		})
+	if err := database.DB.First(&diary, id).Error; err != nil {
+		return errLookup(err, "Diary not found")
+	}
+
+	// PUT เขียนทับทุกฟิลด์ จึงให้เฉพาะเจ้าของ ผู้ดูแลระบบแก้ไขได้เฉพาะฟิลด์ที่ diaryPatchFields อนุญาตผ่าน PATCH
+	if user.ID != diary.StudentID {
+		return errForbidden("Only the owner can replace this diary")
+	}
+
+	if err := requireIfMatch(c, diary); err != nil {
+		return err
+	}
+
+	var updateData UpdateDiaryRequest
+	if err := bindBody(c, &updateData); err != nil {
+		return err
 	}
 
-	var updateData models.Diary
-	if err := c.BodyParser(&updateData); err != nil {
-		return c.Status(400).JSON(fiber.Map{
-			"error":   "Cannot parse JSON",
-			"details": err.Error(),
-		})
+	// ฟิลด์ IsShared, AllowComment และ Status ที่ไม่ระบุให้คงค่าเดิม เพื่อไม่ให้บันทึกส่วนตัวกลายเป็นสาธารณะ
+	// หรือถูกปิดความคิดเห็นและรีเซ็ตอารมณ์โดยไม่ตั้งใจ
+	if updateData.IsShared == "" {
+		updateData.IsShared = diary.IsShared
 	}
 
-	diary.ContentHTML = updateData.ContentHTML
-	diary.ContentDelta = updateData.ContentDelta
-	diary.IsShared = updateData.IsShared
-	diary.AllowComment = updateData.AllowComment
-	diary.Status = updateData.Status
+	allowComment := diary.AllowComment
+	if updateData.AllowComment != nil {
+		allowComment = *updateData.AllowComment
+	}
 
-	if err := database.DB.Save(&diary).Error; err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"error":   "Failed to update diary",
-			"details": err.Error(),
-		})
+	if updateData.Status == "" {
+		updateData.Status = diary.Status
 	}
 
-	return c.JSON(fiber.Map{
-		"message": "Updated diary successfully",
-		"data":    diary,
+	updated, err := updateDiaryVersioned(&diary, map[string]interface{}{
+		"content_html":  updateData.ContentHTML,
+		"content_delta": updateData.ContentDelta,
+		"is_shared":     updateData.IsShared,
+		"allow_comment": allowComment,
+		"status":        updateData.Status,
 	})
+	if err != nil {
+		return errInternal(err, "Failed to update diary")
+	}
+	if !updated {
+		return diaryConflict(c, diary.ID)
+	}
+
+	onDiaryContentSaved(diary)
+
+	c.Set(fiber.HeaderETag, diaryETag(diary))
+	return respond(c, fiber.StatusOK, "Updated diary successfully", diary)
 }
 
 func PatchDiary(c *fiber.Ctx) error {
+	user := currentUser(c)
+	if user == nil {
+		return errUnauthorized("Unauthorized")
+	}
+
 	id := c.Params("id")
 	var diary models.Diary
 
-	if result := database.DB.First(&diary, id); result.Error != nil {
-		return c.Status(404).JSON(fiber.Map{
-			"error": "Diary not found",
-		})
+	if err := database.DB.First(&diary, id).Error; err != nil {
+		return errLookup(err, "Diary not found")
 	}
 
-	var updateData map[string]interface{}
-	if err := c.BodyParser(&updateData); err != nil {
-		return c.Status(400).JSON(fiber.Map{
-			"error":   "Cannot parse JSON",
-			"details": err.Error(),
-		})
+	role := user.Role
+	if user.ID == diary.StudentID {
+		role = "owner"
+	} else if role != "admin" {
+		return errForbidden("You are not allowed to modify this diary")
 	}
 
-	if err := database.DB.Model(&diary).Updates(updateData).Error; err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"error":   "Failed to update diary",
-			"details": err.Error(),
-		})
+	if err := requireIfMatch(c, diary); err != nil {
+		return err
 	}
 
-	return c.JSON(fiber.Map{
-		"message": "Patched diary successfully",
-		"data":    diary,
-	})
+	updateData, fieldErrs, err := applyMergePatch(c.Body(), diaryPatchFields, role)
+	if err != nil {
+		return errBadRequest("Cannot parse JSON").WithTH("รูปแบบข้อมูลไม่ถูกต้อง")
+	}
+	if len(fieldErrs) > 0 {
+		return errValidation(fieldErrs)
+	}
+
+	updated, err := updateDiaryVersioned(&diary, updateData)
+	if err != nil {
+		return errInternal(err, "Failed to update diary")
+	}
+	if !updated {
+		return diaryConflict(c, diary.ID)
+	}
+
+	if _, ok := updateData["content_html"]; ok {
+		onDiaryContentSaved(diary)
+	}
+
+	c.Set(fiber.HeaderETag, diaryETag(diary))
+	return respond(c, fiber.StatusOK, "Patched diary successfully", diary)
 }
 
 func DeleteDiary(c *fiber.Ctx) error {
+	user := currentUser(c)
+	if user == nil {
+		return errUnauthorized("Unauthorized")
+	}
+
 	id := c.Params("id")
 	var diary models.Diary
 
-	if result := database.DB.First(&diary, id); result.Error != nil {
-		return c.Status(404).JSON(fiber.Map{
-			"error": "Diary not found",
-		})
+	if err := database.DB.First(&diary, id).Error; err != nil {
+		return errLookup(err, "Diary not found")
+	}
+
+	if user.ID != diary.StudentID && user.Role != "admin" {
+		return errForbidden("You are not allowed to modify this diary")
+	}
+
+	// reaction อ้างถึงรายการแบบ polymorphic จึงไม่มี foreign key ให้ลบตาม ต้องลบเอง
+	if err := database.DB.
+		Where("(target_type = ? AND target_id = ?) OR (target_type = ? AND target_id IN (?))",
+			ReactionTargetDiary, diary.ID,
+			ReactionTargetComment, database.DB.Model(&models.Comment{}).Select("id").Where("diary_id = ?", diary.ID)).
+		Delete(&models.Reaction{}).Error; err != nil {
+		return errInternal(err, "Failed to delete diary reactions")
 	}
 
 	if err := database.DB.Delete(&diary).Error; err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"error":   "Failed to delete diary",
-			"details": err.Error(),
-		})
+		return errInternal(err, "Failed to delete diary")
 	}
 
-	return c.Status(200).JSON(fiber.Map{
-		"ok":      true,
-		"message": "Deleted diary successfully",
-	})
+	return respond(c, fiber.StatusOK, "Deleted diary successfully", nil)
 }
 
 func GetDiariesByStudent(c *fiber.Ctx) error {
@@ -245,16 +477,15 @@ func GetDiariesByStudent(c *fiber.Ctx) error {
 	if err := database.DB.Where("student_id = ?", studentID).
 		Order("diary_date desc").
 		Find(&diaries).Error; err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"error":   "Failed to retrieve diaries",
-			"details": err.Error(),
-		})
+		return errInternal(err, "Failed to retrieve diaries")
+	}
+
+	if err := decorateDiaries(diaries); err != nil {
+		return errInternal(err, "Failed to load diary details")
 	}
 
-	return c.JSON(fiber.Map{
-		"message": "Diaries retrieved successfully",
-		"data":    diaries,
-		"count":   len(diaries),
+	return respondMeta(c, fiber.StatusOK, "Diaries retrieved successfully", diaries, fiber.Map{
+		"count": len(diaries),
 	})
 }
 
@@ -276,51 +507,41 @@ func GetAllDiaries(c *fiber.Ctx) error {
 	}
 
 	if err := query.Offset(offset).Limit(limit).Find(&diaries).Error; err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"error":   "Failed to retrieve diaries",
-			"details": err.Error(),
-		})
-	}
-
-	return c.JSON(fiber.Map{
-		"message": "Diaries retrieved successfully",
-		"data":    diaries,
-		"count":   len(diaries),
-		"page":    page,
-		"limit":   limit,
+		return errInternal(err, "Failed to retrieve diaries")
+	}
+
+	if err := decorateDiaries(diaries); err != nil {
+		return errInternal(err, "Failed to load diary details")
+	}
+
+	return respondMeta(c, fiber.StatusOK, "Diaries retrieved successfully", diaries, fiber.Map{
+		"count": len(diaries),
+		"page":  page,
+		"limit": limit,
 	})
 }
 
 func GetDiaryDateByStudentId(c *fiber.Ctx) error {
-	studentId := c.Query("StudentID")
-
-	if studentId == "" {
-		return c.Status(400).JSON(fiber.Map{
-			"error": "StudentID is required",
-		})
+	var query struct {
+		StudentID uint `query:"StudentID" validate:"required"`
+	}
+	if err := bindQuery(c, &query); err != nil {
+		return err
 	}
 
-	var diaryDates []time.Time
+	diaryDates := []time.Time{}
 
 	result := database.DB.Model(&models.Diary{}).
-		Where("student_id = ?", studentId).
+		Where("student_id = ?", query.StudentID).
 		Pluck("diary_date", &diaryDates)
 
 	if result.Error != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"error": result.Error.Error(),
-		})
+		return errInternal(result.Error, "Failed to retrieve diary dates")
 	}
 
 	if len(diaryDates) == 0 {
-		return c.JSON(fiber.Map{
-			"message": "no diary dates found",
-			"data":    []time.Time{},
-		})
+		return respond(c, fiber.StatusOK, "no diary dates found", diaryDates)
 	}
 
-	return c.JSON(fiber.Map{
-		"message": "ok",
-		"data":    diaryDates,
-	})
+	return respond(c, fiber.StatusOK, "ok", diaryDates)
 }
@@ -0,0 +1,122 @@
+package controllers
+
+import (
+	"gofiber-auth/database"
+	"gofiber-auth/models"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type UnreadDiaryQuery struct {
+	Page  int `query:"page"`
+	Limit int `query:"limit"`
+}
+
+// markDiaryRead บันทึกว่าผู้ใช้อ่านบันทึกฉบับปัจจุบันแล้ว การอ่านครั้งแรกกำหนด FirstReadAt
+func markDiaryRead(diary models.Diary, userID uint) error {
+	now := time.Now()
+
+	var read models.DiaryRead
+	result := database.DB.Where("diary_id = ? AND user_id = ?", diary.ID, userID).Limit(1).Find(&read)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		read = models.DiaryRead{
+			DiaryID:     diary.ID,
+			UserID:      userID,
+			ReadVersion: diary.Version,
+			FirstReadAt: now,
+			LastReadAt:  now,
+		}
+		return database.DB.Create(&read).Error
+	}
+
+	return database.DB.Model(&read).Updates(map[string]interface{}{
+		"read_version": diary.Version,
+		"last_read_at": now,
+	}).Error
+}
+
+// attachDiaryReads เติมรายชื่อผู้ที่อ่านบันทึกแล้ว พร้อมสถานะว่าอ่านฉบับล่าสุดหรือยัง
+func attachDiaryReads(diaries []models.Diary) error {
+	if len(diaries) == 0 {
+		return nil
+	}
+
+	ids := make([]uint, len(diaries))
+	for i, diary := range diaries {
+		ids[i] = diary.ID
+	}
+
+	var reads []models.DiaryRead
+	if err := database.DB.
+		Preload("User").
+		Where("diary_id IN ?", ids).
+		Order("last_read_at DESC").
+		Find(&reads).Error; err != nil {
+		return err
+	}
+
+	byDiary := make(map[uint][]models.DiaryRead, len(diaries))
+	for _, read := range reads {
+		byDiary[read.DiaryID] = append(byDiary[read.DiaryID], read)
+	}
+	for i := range diaries {
+		seenBy := byDiary[diaries[i].ID]
+		for j := range seenBy {
+			seenBy[j].ReadLatest = seenBy[j].ReadVersion >= diaries[i].Version
+		}
+		diaries[i].SeenBy = seenBy
+	}
+	return nil
+}
+
+// GetUnreadDiaries คืนค่าบันทึกของนิสิตในการดูแลที่อาจารย์ยังไม่ได้อ่าน หรือมีการแก้ไขหลังจากอ่านครั้งล่าสุด
+func GetUnreadDiaries(c *fiber.Ctx) error {
+	user := currentUser(c)
+	if user == nil {
+		return errUnauthorized("Unauthorized")
+	}
+
+	var query UnreadDiaryQuery
+	if err := bindQuery(c, &query); err != nil {
+		return err
+	}
+	page, limit := pageParams(query.Page, query.Limit)
+
+	dbQuery := database.DB.Model(&models.Diary{}).
+		Joins("JOIN student_advisors ON student_advisors.student_id = diaries.student_id AND student_advisors.advisor_id = ?", user.ID).
+		Joins("LEFT JOIN diary_reads ON diary_reads.diary_id = diaries.id AND diary_reads.user_id = ?", user.ID).
+		Where("diaries.is_shared <> ?", "private").
+		Where("diary_reads.id IS NULL OR diary_reads.read_version < diaries.version")
+
+	var total int64
+	if err := dbQuery.Count(&total).Error; err != nil {
+		return errInternal(err, "Failed to count unread diaries")
+	}
+
+	diaries := []models.Diary{}
+	if err := dbQuery.
+		Preload("Student").
+		Select("diaries.*").
+		Order("diaries.diary_date DESC, diaries.id DESC").
+		Offset((page - 1) * limit).
+		Limit(limit).
+		Find(&diaries).Error; err != nil {
+		return errInternal(err, "Failed to retrieve unread diaries")
+	}
+
+	if err := decorateDiaries(diaries); err != nil {
+		return errInternal(err, "Failed to load diary details")
+	}
+
+	return respondMeta(c, fiber.StatusOK, "Unread diaries retrieved successfully", diaries, fiber.Map{
+		"count": len(diaries),
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+}
@@ -0,0 +1,50 @@
+package controllers
+
+import (
+	"gofiber-auth/database"
+	"gofiber-auth/models"
+	"gofiber-auth/sentiment"
+	"log"
+)
+
+// sentimentMismatchThreshold คือระดับ sentiment ที่ถือว่าขัดแย้งกับอารมณ์ที่นิสิตเลือกเอง
+const sentimentMismatchThreshold = 0.3
+
+// analyzeDiarySentimentAsync วิเคราะห์ความรู้สึกจากเนื้อหาบันทึกโดยไม่หน่วง response
+// ผลจะถูกบันทึกเฉพาะเมื่อ version ยังไม่เปลี่ยน เพื่อไม่ให้ผลของเนื้อหาเก่าทับผลของเนื้อหาใหม่
+func analyzeDiarySentimentAsync(diary models.Diary) {
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("Recovered from panic in sentiment analysis for diary %d: %v", diary.ID, r)
+			}
+		}()
+
+		result := sentiment.Analyze(htmlToText(diary.ContentHTML))
+		var score *float64
+		if result.Positive+result.Negative > 0 {
+			score = &result.Score
+		}
+
+		if err := database.DB.Model(&models.Diary{}).
+			Where("id = ? AND version = ?", diary.ID, diary.Version).
+			UpdateColumn("sentiment_score", score).Error; err != nil {
+			log.Printf("Failed to store sentiment for diary %d: %v", diary.ID, err)
+		}
+	}()
+}
+
+// neutralMoodScore คืนค่าคะแนนของอารมณ์เริ่มต้น ใช้เป็นจุดกึ่งกลางเมื่อเทียบกับ sentiment
+func neutralMoodScore() int {
+	scale, err := loadMoodScale()
+	if err != nil || len(scale) == 0 {
+		return 3
+	}
+	key := defaultMoodKey()
+	for _, m := range scale {
+		if m.Key == key {
+			return m.Score
+		}
+	}
+	return scale[len(scale)/2].Score
+}
@@ -0,0 +1,399 @@
+package controllers
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"gofiber-auth/database"
+	"gofiber-auth/mailer"
+	"gofiber-auth/models"
+	"html/template"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// digestPeriodDays คือจำนวนวันที่สรุปในแต่ละฉบับ นับย้อนหลังจากวันก่อนวันส่ง
+const digestPeriodDays = 7
+
+// digestExcerptRunes คือความยาวสูงสุดของข้อความความคิดเห็นที่แสดงในสรุป
+const digestExcerptRunes = 120
+
+//go:embed templates/weekly_digest.html
+var weeklyDigestHTML string
+
+var weeklyDigestTemplate = template.Must(template.New("weekly_digest").Funcs(template.FuncMap{
+	"name": func(name *string) string {
+		if name != nil && *name != "" {
+			return *name
+		}
+		return "ไม่ระบุชื่อ"
+	},
+	"score": func(score *float64) string {
+		if score == nil {
+			return "-"
+		}
+		return fmt.Sprintf("%.1f", *score)
+	},
+}).Parse(weeklyDigestHTML))
+
+type DigestSettingRequest struct {
+	Enabled bool `json:"enabled"`
+	Weekday int  `json:"weekday" validate:"gte=0,lte=6"`
+	Hour    int  `json:"hour" validate:"gte=0,lte=23"`
+}
+
+type DigestPreviewQuery struct {
+	AdvisorID uint   `query:"advisor_id"`
+	Format    string `query:"format" validate:"omitempty,oneof=html json"`
+}
+
+type DigestStudentEntries struct {
+	StudentID    uint     `json:"student_id"`
+	Name         *string  `json:"name"`
+	Entries      int64    `json:"entries"`
+	AverageScore *float64 `json:"average_score"`
+}
+
+type DigestMoodChange struct {
+	StudentID       uint    `json:"student_id"`
+	Name            *string `json:"name"`
+	PreviousAverage float64 `json:"previous_average"`
+	CurrentAverage  float64 `json:"current_average"`
+	Change          float64 `json:"change"`
+}
+
+type DigestUnansweredComment struct {
+	CommentID   uint    `json:"comment_id"`
+	DiaryID     uint    `json:"diary_id"`
+	StudentID   uint    `json:"student_id"`
+	Name        *string `json:"name"`
+	Excerpt     string  `json:"excerpt"`
+	CommentedAt string  `json:"commented_at"`
+}
+
+// WeeklyDigest คือข้อมูลสรุปรายสัปดาห์ของอาจารย์หนึ่งคน ใช้ทั้งในอีเมลและ endpoint preview
+type WeeklyDigest struct {
+	AdvisorID          uint                      `json:"advisor_id"`
+	AdvisorName        string                    `json:"advisor_name"`
+	PeriodStart        string                    `json:"period_start"`
+	PeriodEnd          string                    `json:"period_end"`
+	SupervisedStudents int64                     `json:"supervised_students"`
+	NewEntries         int64                     `json:"new_entries"`
+	StudentsWriting    int64                     `json:"students_writing"`
+	EntriesByStudent   []DigestStudentEntries    `json:"entries_by_student"`
+	MoodChanges        []DigestMoodChange        `json:"mood_changes"`
+	InactiveStudents   []InactiveStudent         `json:"inactive_students"`
+	UnansweredComments []DigestUnansweredComment `json:"unanswered_comments"`
+	UncommentedDiaries int64                     `json:"uncommented_diaries"`
+}
+
+func defaultDigestSetting(advisorID uint) models.DigestSetting {
+	return models.DigestSetting{AdvisorID: advisorID, Weekday: int(time.Monday), Hour: 8}
+}
+
+// digestSettingFor คืนค่าการตั้งค่าสรุปของอาจารย์ หรือค่าเริ่มต้น (ปิดอยู่) หากยังไม่เคยตั้งค่า
+func digestSettingFor(advisorID uint) (models.DigestSetting, error) {
+	var setting models.DigestSetting
+	result := database.DB.Where("advisor_id = ?", advisorID).Limit(1).Find(&setting)
+	if result.Error != nil {
+		return setting, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return defaultDigestSetting(advisorID), nil
+	}
+	return setting, nil
+}
+
+// excerpt ตัดข้อความให้ไม่เกิน n ตัวอักษร
+func excerpt(s string, n int) string {
+	s = strings.Join(strings.Fields(htmlToText(s)), " ")
+	if runes := []rune(s); len(runes) > n {
+		return string(runes[:n]) + "…"
+	}
+	return s
+}
+
+// buildWeeklyDigest รวบรวมข้อมูลของนิสิตในการดูแลช่วง digestPeriodDays วันก่อน today
+func buildWeeklyDigest(advisor models.User, today time.Time) (*WeeklyDigest, error) {
+	periodStart := today.AddDate(0, 0, -digestPeriodDays)
+	periodEnd := today.AddDate(0, 0, -1)
+	previousStart := periodStart.AddDate(0, 0, -digestPeriodDays)
+
+	digest := &WeeklyDigest{
+		AdvisorID:          advisor.ID,
+		AdvisorName:        userDisplayName(advisor),
+		PeriodStart:        periodStart.Format(dateLayout),
+		PeriodEnd:          periodEnd.Format(dateLayout),
+		EntriesByStudent:   []DigestStudentEntries{},
+		MoodChanges:        []DigestMoodChange{},
+		UnansweredComments: []DigestUnansweredComment{},
+	}
+
+	if err := database.DB.Model(&models.StudentAdvisor{}).
+		Where("advisor_id = ?", advisor.ID).
+		Count(&digest.SupervisedStudents).Error; err != nil {
+		return nil, err
+	}
+
+	supervised := database.DB.Model(&models.Diary{}).
+		Joins("JOIN student_advisors ON student_advisors.student_id = diaries.student_id").
+		Joins("JOIN users ON users.id = diaries.student_id").
+		Where("student_advisors.advisor_id = ?", advisor.ID).
+		Session(&gorm.Session{})
+
+	if err := supervised.
+		Joins("LEFT JOIN mood_scales ON mood_scales.mood_key = diaries.status").
+		Where("diaries.diary_date BETWEEN ? AND ?", digest.PeriodStart, digest.PeriodEnd).
+		Select("diaries.student_id AS student_id, users.name AS name, COUNT(*) AS entries, AVG(mood_scales.score) AS average_score").
+		Group("diaries.student_id, users.name").
+		Order("entries DESC, users.name ASC").
+		Scan(&digest.EntriesByStudent).Error; err != nil {
+		return nil, err
+	}
+	for _, s := range digest.EntriesByStudent {
+		digest.NewEntries += s.Entries
+	}
+	digest.StudentsWriting = int64(len(digest.EntriesByStudent))
+
+	// เปรียบเทียบคะแนนเฉลี่ยกับสัปดาห์ก่อน แสดงเฉพาะที่เปลี่ยนอย่างน้อยตามเกณฑ์ของการแจ้งเตือนอารมณ์
+	setting, err := moodAlertSettingFor(advisor.ID)
+	if err != nil {
+		return nil, err
+	}
+	var changes []DigestMoodChange
+	if err := supervised.
+		Joins("JOIN mood_scales ON mood_scales.mood_key = diaries.status").
+		Where("diaries.diary_date BETWEEN ? AND ?", previousStart.Format(dateLayout), digest.PeriodEnd).
+		Select("diaries.student_id AS student_id, users.name AS name, "+
+			"AVG(CASE WHEN diaries.diary_date < ? THEN mood_scales.score END) AS previous_average, "+
+			"AVG(CASE WHEN diaries.diary_date >= ? THEN mood_scales.score END) AS current_average",
+			digest.PeriodStart, digest.PeriodStart).
+		Group("diaries.student_id, users.name").
+		Having("previous_average IS NOT NULL AND current_average IS NOT NULL").
+		Scan(&changes).Error; err != nil {
+		return nil, err
+	}
+	for _, change := range changes {
+		change.Change = math.Round((change.CurrentAverage-change.PreviousAverage)*100) / 100
+		if math.Abs(change.Change) >= setting.DropThreshold {
+			digest.MoodChanges = append(digest.MoodChanges, change)
+		}
+	}
+	sort.SliceStable(digest.MoodChanges, func(i, j int) bool {
+		return digest.MoodChanges[i].Change < digest.MoodChanges[j].Change
+	})
+
+	inactive, err := findInactiveStudents(inactivityDays(), func(db *gorm.DB) *gorm.DB {
+		return db.Joins("JOIN student_advisors ON student_advisors.student_id = users.id").
+			Where("student_advisors.advisor_id = ?", advisor.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	digest.InactiveStudents = inactive
+
+	// ความคิดเห็นของนิสิตในบันทึกของตนเองที่อาจารย์ยังไม่ได้ตอบหลังจากนั้น
+	// ไม่รวมบันทึกส่วนตัวและความคิดเห็นที่ถูกซ่อน เพราะเนื้อหาจะถูกส่งออกไปทางอีเมล
+	var comments []struct {
+		DigestUnansweredComment
+		Content   string
+		CreatedAt time.Time
+	}
+	if err := database.DB.Model(&models.Comment{}).
+		Joins("JOIN diaries ON diaries.id = comments.diary_id AND diaries.student_id = comments.author_id").
+		Joins("JOIN student_advisors ON student_advisors.student_id = diaries.student_id").
+		Joins("JOIN users ON users.id = comments.author_id").
+		Where("student_advisors.advisor_id = ? AND comments.created_at >= ?", advisor.ID, periodStart).
+		Where("diaries.is_shared <> ? AND comments.hidden = ?", "private", false).
+		Where("NOT EXISTS (SELECT 1 FROM comments replies WHERE replies.diary_id = comments.diary_id "+
+			"AND replies.author_id = ? AND replies.created_at > comments.created_at)", advisor.ID).
+		Select("comments.id AS comment_id, comments.diary_id AS diary_id, diaries.student_id AS student_id, " +
+			"users.name AS name, comments.content AS content, comments.created_at AS created_at").
+		Order("comments.created_at ASC").
+		Limit(dashboardListLimit).
+		Scan(&comments).Error; err != nil {
+		return nil, err
+	}
+	for _, comment := range comments {
+		item := comment.DigestUnansweredComment
+		item.Excerpt = excerpt(comment.Content, digestExcerptRunes)
+		item.CommentedAt = comment.CreatedAt.Format("2006-01-02 15:04")
+		digest.UnansweredComments = append(digest.UnansweredComments, item)
+	}
+
+	if err := supervised.
+		Where("diaries.diary_date BETWEEN ? AND ? AND diaries.is_shared <> ?", digest.PeriodStart, digest.PeriodEnd, "private").
+		Where("NOT EXISTS (SELECT 1 FROM comments WHERE comments.diary_id = diaries.id AND comments.author_id = ?)", advisor.ID).
+		Count(&digest.UncommentedDiaries).Error; err != nil {
+		return nil, err
+	}
+
+	return digest, nil
+}
+
+func renderWeeklyDigest(digest *WeeklyDigest) (string, error) {
+	var buf bytes.Buffer
+	if err := weeklyDigestTemplate.Execute(&buf, digest); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// digestDue ตรวจว่าถึงเวลาส่งสรุปของสัปดาห์นี้และยังไม่ได้ส่ง now ต้องเป็นเวลาประเทศไทย
+func digestDue(setting models.DigestSetting, now time.Time) bool {
+	if int(now.Weekday()) != setting.Weekday || now.Hour() < setting.Hour {
+		return false
+	}
+	scheduled := time.Date(now.Year(), now.Month(), now.Day(), setting.Hour, 0, 0, 0, now.Location())
+	return setting.LastSentAt == nil || setting.LastSentAt.Before(scheduled)
+}
+
+// sendWeeklyDigests ส่งสรุปทางอีเมลให้อาจารย์ที่เปิดรับและถึงเวลาตามที่ตั้งไว้ ใช้โดย scheduler
+func sendWeeklyDigests() error {
+	var settings []models.DigestSetting
+	if err := database.DB.Preload("Advisor").Where("enabled = ?", true).Find(&settings).Error; err != nil {
+		return err
+	}
+
+	loc, _ := time.LoadLocation("Asia/Bangkok")
+	now := time.Now().In(loc)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	for _, setting := range settings {
+		if !digestDue(setting, now) {
+			continue
+		}
+
+		digest, err := buildWeeklyDigest(setting.Advisor, today)
+		if err != nil {
+			log.Printf("Failed to build digest for advisor %d: %v", setting.AdvisorID, err)
+			continue
+		}
+		body, err := renderWeeklyDigest(digest)
+		if err != nil {
+			log.Printf("Failed to render digest for advisor %d: %v", setting.AdvisorID, err)
+			continue
+		}
+
+		if err := mailer.Send(mailer.Message{
+			To:      []string{setting.Advisor.Email},
+			Subject: fmt.Sprintf("สรุปบันทึกประจำสัปดาห์ %s ถึง %s", digest.PeriodStart, digest.PeriodEnd),
+			Body:    body,
+			HTML:    true,
+		}); err != nil {
+			log.Printf("Failed to email digest to advisor %d: %v", setting.AdvisorID, err)
+			continue
+		}
+
+		if err := database.DB.Model(&setting).Update("last_sent_at", time.Now()).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func GetDigestSetting(c *fiber.Ctx) error {
+	user := currentUser(c)
+	if user == nil {
+		return errUnauthorized("Unauthorized")
+	}
+
+	setting, err := digestSettingFor(user.ID)
+	if err != nil {
+		return errInternal(err, "Failed to load digest settings")
+	}
+
+	return respond(c, fiber.StatusOK, "Digest settings retrieved successfully", setting)
+}
+
+func UpdateDigestSetting(c *fiber.Ctx) error {
+	user := currentUser(c)
+	if user == nil {
+		return errUnauthorized("Unauthorized")
+	}
+
+	var input DigestSettingRequest
+	if err := bindBody(c, &input); err != nil {
+		return err
+	}
+
+	setting, err := digestSettingFor(user.ID)
+	if err != nil {
+		return errInternal(err, "Failed to load digest settings")
+	}
+
+	setting.Enabled = input.Enabled
+	setting.Weekday = input.Weekday
+	setting.Hour = input.Hour
+
+	if setting.ID == 0 {
+		err = database.DB.Create(&setting).Error
+		// Create ข้ามค่าศูนย์ของคอลัมน์ที่มีค่าเริ่มต้น เช่น วันอาทิตย์ (0) หรือเที่ยงคืน จึงต้องอัปเดตซ้ำ
+		if err == nil && (input.Weekday == 0 || input.Hour == 0) {
+			err = database.DB.Model(&setting).Updates(map[string]interface{}{
+				"weekday": input.Weekday,
+				"hour":    input.Hour,
+			}).Error
+		}
+	} else {
+		err = database.DB.Save(&setting).Error
+	}
+	if err != nil {
+		return errInternal(err, "Failed to save digest settings")
+	}
+
+	return respond(c, fiber.StatusOK, "Digest settings updated successfully", setting)
+}
+
+// PreviewWeeklyDigest แสดงสรุปรายสัปดาห์ที่จะส่งหากส่งวันนี้ ผู้ดูแลระบบระบุ advisor_id เพื่อดูของอาจารย์คนอื่นได้
+func PreviewWeeklyDigest(c *fiber.Ctx) error {
+	user := currentUser(c)
+	if user == nil {
+		return errUnauthorized("Unauthorized")
+	}
+
+	var query DigestPreviewQuery
+	if err := bindQuery(c, &query); err != nil {
+		return err
+	}
+
+	advisorID := user.ID
+	if query.AdvisorID != 0 && query.AdvisorID != user.ID {
+		if user.Role != "admin" {
+			return errForbidden("You can only preview your own digest")
+		}
+		advisorID = query.AdvisorID
+	}
+
+	var advisor models.User
+	if err := database.DB.Where("id = ? AND role = ?", advisorID, "advisor").First(&advisor).Error; err != nil {
+		return errLookup(err, "Advisor not found")
+	}
+
+	loc, _ := time.LoadLocation("Asia/Bangkok")
+	now := time.Now().In(loc)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	digest, err := buildWeeklyDigest(advisor, today)
+	if err != nil {
+		return errInternal(err, "Failed to build digest")
+	}
+
+	if query.Format == "json" {
+		return respond(c, fiber.StatusOK, "Digest preview generated successfully", digest)
+	}
+
+	body, err := renderWeeklyDigest(digest)
+	if err != nil {
+		return errInternal(err, "Failed to render digest")
+	}
+	c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+	return c.SendString(body)
+}
@@ -4,76 +4,46 @@ import (
 	"fmt"
 	"gofiber-auth/database"
 	"gofiber-auth/models"
-	"strconv"
 
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
 )
 
 func GetGroupsByAdvisor(c *fiber.Ctx) error {
-	advisorIDStr := c.Query("advisor_id")
-
-	if advisorIDStr == "" {
-		return c.Status(400).JSON(fiber.Map{
-			"error": "Advisor ID is required",
-		})
+	var query AdvisorQuery
+	if err := bindQuery(c, &query); err != nil {
+		return err
 	}
 
 	var groups []models.Group
-	result := database.DB.Where("advisor_id = ?", advisorIDStr).Find(&groups)
+	result := database.DB.Where("advisor_id = ?", query.AdvisorID).Find(&groups)
 
 	if result.Error != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"error": result.Error.Error(),
-		})
+		return errInternal(result.Error, "Failed to retrieve groups")
 	}
 
-	return c.JSON(fiber.Map{
-		"message": "Groups retrieved successfully",
-		"data":    groups,
-	})
+	return respond(c, fiber.StatusOK, "Groups retrieved successfully", groups)
 }
 
-func CreateGroup(c *fiber.Ctx) error {
-	type Input struct {
-		Name        string  `json:"name,omitempty"`
-		Description *string `json:"description,omitempty"`
-		AdvisorID   uint    `json:"advisor_id,omitempty"`
-	}
-
-	var input Input
-	if err := c.BodyParser(&input); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"message": "Invalid JSON body",
-			"error":   err.Error(),
-		})
-	}
-
-	if input.Name == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"message": "Group name is required",
-		})
-	}
+type CreateGroupRequest struct {
+	Name        string  `json:"name,omitempty" validate:"required,max=255"`
+	Description *string `json:"description,omitempty" validate:"omitempty,max=1000"`
+	AdvisorID   uint    `json:"advisor_id,omitempty" validate:"required"`
+}
 
-	if input.AdvisorID == 0 {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"message": "Advisor ID is required",
-		})
+func CreateGroup(c *fiber.Ctx) error {
+	var input CreateGroupRequest
+	if err := bindBody(c, &input); err != nil {
+		return err
 	}
 
 	var advisor models.User
 	if err := database.DB.First(&advisor, input.AdvisorID).Error; err != nil {
-		fmt.Printf("Error finding advisor ID %d: %v\n", input.AdvisorID, err)
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"message": fmt.Sprintf("Advisor with ID %d not found", input.AdvisorID),
-			"error":   err.Error(),
-		})
+		return errLookup(err, fmt.Sprintf("Advisor with ID %d not found", input.AdvisorID))
 	}
 
 	if advisor.Role != "advisor" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"message": fmt.Sprintf("User with ID %d is not an advisor (role: %s)", input.AdvisorID, advisor.Role),
-		})
+		return errBadRequest(fmt.Sprintf("User with ID %d is not an advisor (role: %s)", input.AdvisorID, advisor.Role))
 	}
 
 	group := models.Group{
@@ -83,38 +53,28 @@ func CreateGroup(c *fiber.Ctx) error {
 	}
 
 	if err := database.DB.Create(&group).Error; err != nil {
-		fmt.Printf("Error creating group: %v\n", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"message": "Failed to create group",
-			"error":   err.Error(),
-		})
+		return errInternal(err, "Failed to create group")
 	}
 
-	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
-		"message": "Group created successfully",
-		"data":    group,
-	})
+	return respond(c, fiber.StatusCreated, "Group created successfully", group)
 }
 
 func GetStudentsInGroup(c *fiber.Ctx) error {
-	groupIDStr := c.Query("group_id")
-
-	if groupIDStr == "" {
-		return c.Status(400).JSON(fiber.Map{
-			"error": "Group ID is required",
-		})
+	var query struct {
+		GroupID uint `query:"group_id" validate:"required"`
+	}
+	if err := bindQuery(c, &query); err != nil {
+		return err
 	}
 
 	var studentGroups []models.StudentGroup
 	result := database.DB.
 		Preload("Student").
-		Where("group_id = ?", groupIDStr).
+		Where("group_id = ?", query.GroupID).
 		Find(&studentGroups)
 
 	if result.Error != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"error": result.Error.Error(),
-		})
+		return errInternal(result.Error, "Failed to retrieve students")
 	}
 
 	var students []fiber.Map
@@ -129,37 +89,29 @@ func GetStudentsInGroup(c *fiber.Ctx) error {
 		})
 	}
 
-	return c.JSON(fiber.Map{
-		"message": "Students retrieved successfully",
-		"data":    students,
-	})
+	return respond(c, fiber.StatusOK, "Students retrieved successfully", students)
 }
 
 func GetStudentsWithoutGroup(c *fiber.Ctx) error {
-	advisorIDStr := c.Query("advisor_id")
-
-	if advisorIDStr == "" {
-		return c.Status(400).JSON(fiber.Map{
-			"error": "Advisor ID is required",
-		})
+	var query AdvisorQuery
+	if err := bindQuery(c, &query); err != nil {
+		return err
 	}
 
 	var studentAdvisors []models.StudentAdvisor
 	result := database.DB.
 		Preload("Student").
-		Where("advisor_id = ?", advisorIDStr).
+		Where("advisor_id = ?", query.AdvisorID).
 		Find(&studentAdvisors)
 
 	if result.Error != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"error": result.Error.Error(),
-		})
+		return errInternal(result.Error, "Failed to retrieve students")
 	}
 
 	var studentIdsInGroups []uint
 	database.DB.Model(&models.StudentGroup{}).
 		Joins("JOIN groups ON student_groups.group_id = groups.id").
-		Where("groups.advisor_id = ?", advisorIDStr).
+		Where("groups.advisor_id = ?", query.AdvisorID).
 		Pluck("student_groups.student_id", &studentIdsInGroups)
 
 	var studentsWithoutGroup []fiber.Map
@@ -182,30 +134,18 @@ func GetStudentsWithoutGroup(c *fiber.Ctx) error {
 		}
 	}
 
-	return c.JSON(fiber.Map{
-		"message": "Students without group retrieved successfully",
-		"data":    studentsWithoutGroup,
-	})
+	return respond(c, fiber.StatusOK, "Students without group retrieved successfully", studentsWithoutGroup)
 }
 
-func AddStudentToGroup(c *fiber.Ctx) error {
-	type Input struct {
-		StudentID uint `json:"student_id"`
-		GroupID   uint `json:"group_id"`
-	}
-
-	var input Input
-	if err := c.BodyParser(&input); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"message": "Invalid JSON body",
-			"error":   err.Error(),
-		})
-	}
+type StudentGroupRequest struct {
+	StudentID uint `json:"student_id" query:"student_id" validate:"required"`
+	GroupID   uint `json:"group_id" query:"group_id" validate:"required"`
+}
 
-	if input.StudentID == 0 || input.GroupID == 0 {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"message": "Student ID and Group ID are required and must be greater than 0",
-		})
+func AddStudentToGroup(c *fiber.Ctx) error {
+	var input StudentGroupRequest
+	if err := bindBody(c, &input); err != nil {
+		return err
 	}
 
 	tx := database.DB.Begin()
@@ -216,37 +156,25 @@ func AddStudentToGroup(c *fiber.Ctx) error {
 	}()
 
 	if tx.Error != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"message": "Failed to begin transaction",
-		})
+		return errInternal(tx.Error, "Failed to begin transaction")
 	}
 
 	var student models.User
 	if err := tx.First(&student, input.StudentID).Error; err != nil {
 		tx.Rollback()
 		if err == gorm.ErrRecordNotFound {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"message": "Student not found",
-			})
+			return errBadRequest("Student not found")
 		}
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"message": "Error checking student",
-			"error":   err.Error(),
-		})
+		return errInternal(err, "Error checking student")
 	}
 
 	var group models.Group
 	if err := tx.First(&group, input.GroupID).Error; err != nil {
 		tx.Rollback()
 		if err == gorm.ErrRecordNotFound {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"message": "Group not found",
-			})
+			return errBadRequest("Group not found")
 		}
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"message": "Error checking group",
-			"error":   err.Error(),
-		})
+		return errInternal(err, "Error checking group")
 	}
 
 	var existing models.StudentGroup
@@ -256,15 +184,10 @@ func AddStudentToGroup(c *fiber.Ctx) error {
 
 	if err == nil {
 		tx.Rollback()
-		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
-			"message": "Student is already in this group",
-		})
+		return errConflict("Student is already in this group")
 	} else if err != gorm.ErrRecordNotFound {
 		tx.Rollback()
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"message": "Database error when checking existing relationship",
-			"error":   err.Error(),
-		})
+		return errInternal(err, "Database error when checking existing relationship")
 	}
 
 	studentGroup := models.StudentGroup{
@@ -274,99 +197,64 @@ func AddStudentToGroup(c *fiber.Ctx) error {
 
 	if err := tx.Create(&studentGroup).Error; err != nil {
 		tx.Rollback()
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"message": "Failed to add student to group",
-			"error":   err.Error(),
-		})
+		return errInternal(err, "Failed to add student to group")
 	}
 
 	if err := tx.Commit().Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"message": "Failed to commit transaction",
-			"error":   err.Error(),
-		})
+		return errInternal(err, "Failed to commit transaction")
 	}
 
-	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
-		"message": "Student added to group successfully",
-		"data":    studentGroup,
-	})
+	return respond(c, fiber.StatusCreated, "Student added to group successfully", studentGroup)
 }
 
 func RemoveStudentFromGroup(c *fiber.Ctx) error {
-	studentIDStr := c.Query("student_id")
-	groupIDStr := c.Query("group_id")
-
-	if studentIDStr == "" || groupIDStr == "" {
-		return c.Status(400).JSON(fiber.Map{
-			"error": "Student ID and Group ID are required",
-		})
+	var query StudentGroupRequest
+	if err := bindQuery(c, &query); err != nil {
+		return err
 	}
 
-	result := database.DB.Where("student_id = ? AND group_id = ?", studentIDStr, groupIDStr).
+	result := database.DB.Where("student_id = ? AND group_id = ?", query.StudentID, query.GroupID).
 		Delete(&models.StudentGroup{})
 
 	if result.Error != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"error": result.Error.Error(),
-		})
+		return errInternal(result.Error, "Failed to remove student from group")
 	}
 
 	if result.RowsAffected == 0 {
-		return c.Status(404).JSON(fiber.Map{
-			"error": "Student-group relationship not found",
-		})
+		return errNotFound("Student-group relationship not found")
 	}
 
-	return c.JSON(fiber.Map{
-		"ok":      true,
-		"message": "Student removed from group successfully",
-	})
+	return respond(c, fiber.StatusOK, "Student removed from group successfully", nil)
 }
 
 func DeleteGroup(c *fiber.Ctx) error {
-	idParam := c.Query("id")
-	if idParam == "" {
-		return c.Status(400).JSON(fiber.Map{
-			"error": "Group ID is required",
-		})
-	}
-
-	id, err := strconv.ParseUint(idParam, 10, 64)
-	if err != nil {
-		return c.Status(400).JSON(fiber.Map{
-			"error": "Invalid ID format",
-		})
+	var query IDQuery
+	if err := bindQuery(c, &query); err != nil {
+		return err
 	}
+	id := query.ID
 
 	tx := database.DB.Begin()
 
 	if err := tx.Where("group_id = ?", id).Delete(&models.StudentGroup{}).Error; err != nil {
 		tx.Rollback()
-		return c.Status(500).JSON(fiber.Map{
-			"error": "Failed to delete group relationships",
-		})
+		return errInternal(err, "Failed to delete group relationships")
 	}
 
 	result := tx.Delete(&models.Group{}, id)
 	if result.Error != nil {
 		tx.Rollback()
-		return c.Status(500).JSON(fiber.Map{
-			"error": result.Error.Error(),
-		})
+		return errInternal(result.Error, "Failed to delete group")
 	}
 
 	if result.RowsAffected == 0 {
 		tx.Rollback()
-		return c.Status(404).JSON(fiber.Map{
-			"error": "Group not found",
-		})
+		return errNotFound("Group not found")
 	}
 
-	tx.Commit()
+	if err := tx.Commit().Error; err != nil {
+		return errInternal(err, "Failed to commit transaction")
+	}
 
-	return c.JSON(fiber.Map{
-		"ok":      true,
-		"message": "Group deleted successfully",
-	})
+	return respond(c, fiber.StatusOK, "Group deleted successfully", nil)
 }
@@ -0,0 +1,25 @@
+package controllers
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+var (
+	htmlBlockTag = regexp.MustCompile(`(?i)<\s*(br|/p|/div|/li|/h[1-6])\s*/?>`)
+	htmlTag      = regexp.MustCompile(`<[^>]*>`)
+	spaceRun     = regexp.MustCompile(`[ \t\r\f\v]+`)
+	blankLines   = regexp.MustCompile(`\n\s*\n+`)
+)
+
+// htmlToText แปลง ContentHTML จาก editor เป็นข้อความธรรมดา โดยคงการขึ้นบรรทัดของย่อหน้าไว้
+func htmlToText(s string) string {
+	s = htmlBlockTag.ReplaceAllString(s, "\n")
+	s = htmlTag.ReplaceAllString(s, "")
+	s = html.UnescapeString(s)
+	s = strings.ReplaceAll(s, "\u00a0", " ")
+	s = spaceRun.ReplaceAllString(s, " ")
+	s = blankLines.ReplaceAllString(s, "\n")
+	return strings.TrimSpace(s)
+}
@@ -0,0 +1,148 @@
+package controllers
+
+import (
+	"fmt"
+	"gofiber-auth/database"
+	"gofiber-auth/mailer"
+	"gofiber-auth/models"
+	"log"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// inactivityDays คือจำนวนวันที่ไม่มีบันทึกก่อนถือว่านิสิตขาดการเขียน ตั้งค่าผ่าน INACTIVITY_DAYS
+func inactivityDays() int {
+	return envInt("INACTIVITY_DAYS", 3)
+}
+
+type InactiveStudentsQuery struct {
+	AdvisorID uint `query:"advisor_id"`
+	Days      int  `query:"days" validate:"omitempty,gte=1,lte=365"`
+}
+
+// InactiveStudent คือนิสิตที่ไม่ได้เขียนบันทึกตามจำนวนวันที่กำหนด
+// หากไม่เคยเขียนเลย SinceDate คือวันที่สมัครบัญชี
+type InactiveStudent struct {
+	StudentID     uint       `json:"student_id"`
+	Name          *string    `json:"name"`
+	Email         string     `json:"email"`
+	LastDiaryDate *time.Time `json:"last_diary_date"`
+	SinceDate     time.Time  `json:"since_date"`
+	DaysInactive  int        `json:"days_inactive"`
+}
+
+// findInactiveStudents คืนค่านิสิตที่ไม่มีบันทึกมาอย่างน้อย days วัน โดย scope ใช้จำกัดกลุ่มนิสิต
+func findInactiveStudents(days int, scope func(*gorm.DB) *gorm.DB) ([]InactiveStudent, error) {
+	loc, _ := time.LoadLocation("Asia/Bangkok")
+	now := time.Now().In(loc)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	cutoff := today.AddDate(0, 0, -days)
+
+	query := database.DB.Model(&models.User{}).
+		Joins("LEFT JOIN diaries ON diaries.student_id = users.id").
+		Where("users.role = ? AND users.approved = ?", "student", true)
+	if scope != nil {
+		query = scope(query)
+	}
+
+	students := []InactiveStudent{}
+	err := query.
+		Select("users.id AS student_id, users.name AS name, users.email AS email, "+
+			"MAX(diaries.diary_date) AS last_diary_date, "+
+			"COALESCE(MAX(diaries.diary_date), DATE(users.created_at)) AS since_date").
+		Group("users.id, users.name, users.email, users.created_at").
+		Having("COALESCE(MAX(diaries.diary_date), DATE(users.created_at)) <= ?", cutoff.Format(dateLayout)).
+		Order("since_date ASC").
+		Scan(&students).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range students {
+		since := students[i].SinceDate
+		sinceDay := time.Date(since.Year(), since.Month(), since.Day(), 0, 0, 0, 0, time.UTC)
+		students[i].DaysInactive = int(today.Sub(sinceDay).Hours() / 24)
+	}
+
+	return students, nil
+}
+
+// sendInactivityReminders ส่งการแจ้งเตือนในระบบและอีเมลให้นิสิตที่ขาดการเขียน
+// นิสิตแต่ละคนได้รับการแจ้งเตือนไม่เกินหนึ่งครั้งต่อช่วง INACTIVITY_DAYS
+func sendInactivityReminders() error {
+	days := inactivityDays()
+	students, err := findInactiveStudents(days, nil)
+	if err != nil {
+		return err
+	}
+
+	since := time.Now().AddDate(0, 0, -days)
+	for _, student := range students {
+		var recent int64
+		if err := database.DB.Model(&models.Notification{}).
+			Where("user_id = ? AND type = ? AND created_at >= ?", student.StudentID, "journal_reminder", since).
+			Count(&recent).Error; err != nil {
+			return err
+		}
+		if recent > 0 {
+			continue
+		}
+
+		message := fmt.Sprintf("คุณไม่ได้เขียนบันทึกมา %d วันแล้ว มาเล่าเรื่องราวของวันนี้กันเถอะ", student.DaysInactive)
+		notif := models.Notification{
+			UserID:  student.StudentID,
+			Type:    "journal_reminder",
+			Title:   "ถึงเวลาเขียนบันทึกแล้ว",
+			Message: message,
+		}
+		if err := notifyUser(&notif); err != nil {
+			return err
+		}
+
+		if err := mailer.Send(mailer.Message{
+			To:      []string{student.Email},
+			Subject: "ถึงเวลาเขียนบันทึกแล้ว",
+			Body:    message,
+		}); err != nil {
+			log.Printf("Failed to email reminder to student %d: %v", student.StudentID, err)
+		}
+	}
+
+	return nil
+}
+
+func GetInactiveStudentsByAdvisor(c *fiber.Ctx) error {
+	user := currentUser(c)
+	if user == nil {
+		return errUnauthorized("Unauthorized")
+	}
+
+	var query InactiveStudentsQuery
+	if err := bindQuery(c, &query); err != nil {
+		return err
+	}
+	advisorID, err := scopedAdvisorID(user, query.AdvisorID)
+	if err != nil {
+		return err
+	}
+
+	days := query.Days
+	if days == 0 {
+		days = inactivityDays()
+	}
+
+	students, err := findInactiveStudents(days, func(db *gorm.DB) *gorm.DB {
+		return db.Joins("JOIN student_advisors ON student_advisors.student_id = users.id").
+			Where("student_advisors.advisor_id = ?", advisorID)
+	})
+	if err != nil {
+		return errInternal(err, "Failed to find inactive students")
+	}
+
+	return respondMeta(c, fiber.StatusOK, "Inactive students retrieved successfully", students, fiber.Map{
+		"count": len(students),
+		"days":  days,
+	})
+}
@@ -0,0 +1,223 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"gofiber-auth/database"
+	"gofiber-auth/models"
+	"log"
+	"strings"
+	"unicode"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type MentionQuery struct {
+	Page  int `query:"page"`
+	Limit int `query:"limit"`
+}
+
+// mentionCandidate คือผู้ใช้ที่ถูกกล่าวถึงได้ พร้อมชื่อที่ใช้จับคู่กับข้อความหลัง @ (ตัวพิมพ์เล็ก)
+type mentionCandidate struct {
+	User  models.User
+	Names []string
+}
+
+// mentionCandidates สร้างชื่อที่ใช้จับคู่ของแต่ละคน ได้แก่ชื่อเต็ม ชื่อที่ไม่มีช่องว่าง และส่วนหน้า @ ของอีเมล
+func mentionCandidates(users []models.User) []mentionCandidate {
+	candidates := make([]mentionCandidate, 0, len(users))
+	for _, user := range users {
+		var names []string
+		if user.Name != nil && strings.TrimSpace(*user.Name) != "" {
+			name := strings.ToLower(strings.Join(strings.Fields(*user.Name), " "))
+			names = append(names, name)
+			if compact := strings.ReplaceAll(name, " ", ""); compact != name {
+				names = append(names, compact)
+			}
+		}
+		if local, _, ok := strings.Cut(user.Email, "@"); ok && local != "" {
+			names = append(names, strings.ToLower(local))
+		}
+		candidates = append(candidates, mentionCandidate{User: user, Names: names})
+	}
+	return candidates
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '.'
+}
+
+// resolveMentions หาผู้ใช้ที่ถูกกล่าวถึงด้วย @ชื่อ ในข้อความ
+// ชื่ออาจมีช่องว่างได้ จึงเลือกชื่อที่ยาวที่สุดที่ตรงกับข้อความหลัง @
+// ชื่อภาษาอังกฤษต้องจบที่ขอบคำ ส่วนภาษาไทยซึ่งไม่เว้นวรรคจับคู่ได้ทันที
+func resolveMentions(text string, candidates []mentionCandidate) []models.User {
+	lower := []rune(strings.ToLower(text))
+	seen := map[uint]bool{}
+	var mentioned []models.User
+
+	for i, r := range lower {
+		if r != '@' || i > 0 && isWordRune(lower[i-1]) {
+			continue
+		}
+		rest := lower[i+1:]
+
+		best, bestLen := -1, 0
+		for c, candidate := range candidates {
+			for _, name := range candidate.Names {
+				n := []rune(name)
+				if len(n) <= bestLen || len(n) > len(rest) || string(rest[:len(n)]) != name {
+					continue
+				}
+				last := n[len(n)-1]
+				if len(rest) > len(n) && !isThai(last) && isWordRune(last) && isWordRune(rest[len(n)]) {
+					continue
+				}
+				best, bestLen = c, len(n)
+			}
+		}
+
+		if best >= 0 && !seen[candidates[best].User.ID] {
+			seen[candidates[best].User.ID] = true
+			mentioned = append(mentioned, candidates[best].User)
+		}
+	}
+
+	return mentioned
+}
+
+func isThai(r rune) bool {
+	return r >= 0x0E00 && r <= 0x0E7F
+}
+
+// recordMentions บันทึกการกล่าวถึงในบันทึก (commentID เป็น nil) หรือความคิดเห็น
+// และส่งการแจ้งเตือน mention ให้ผู้ที่ถูกกล่าวถึงใหม่ การบันทึกซ้ำจึงไม่แจ้งเตือนคนเดิมอีก
+func recordMentions(diary models.Diary, commentID *uint, authorID uint, text string) error {
+	if !strings.Contains(text, "@") {
+		return nil
+	}
+
+	audience, err := diaryAudience(diary)
+	if err != nil {
+		return err
+	}
+	mentioned := resolveMentions(text, mentionCandidates(audience))
+	if len(mentioned) == 0 {
+		return nil
+	}
+
+	existing := database.DB.Model(&models.Mention{}).Where("diary_id = ?", diary.ID)
+	if commentID != nil {
+		existing = existing.Where("comment_id = ?", *commentID)
+	} else {
+		existing = existing.Where("comment_id IS NULL")
+	}
+	var existingIDs []uint
+	if err := existing.Pluck("user_id", &existingIDs).Error; err != nil {
+		return err
+	}
+	already := map[uint]bool{authorID: true}
+	for _, id := range existingIDs {
+		already[id] = true
+	}
+
+	var author models.User
+	if err := database.DB.First(&author, authorID).Error; err != nil {
+		return err
+	}
+
+	where := "บันทึก"
+	if commentID != nil {
+		where = "ความคิดเห็น"
+	}
+
+	for _, user := range mentioned {
+		if already[user.ID] {
+			continue
+		}
+
+		mention := models.Mention{
+			UserID:      user.ID,
+			MentionedBy: authorID,
+			DiaryID:     diary.ID,
+			CommentID:   commentID,
+		}
+		if err := database.DB.Create(&mention).Error; err != nil {
+			return err
+		}
+
+		data := map[string]interface{}{
+			"diary_date": diary.DiaryDate.Format(dateLayout),
+			"student_id": diary.StudentID,
+			"mention_id": mention.ID,
+		}
+		if commentID != nil {
+			data["comment_id"] = *commentID
+		}
+		dataJSON, _ := json.Marshal(data)
+
+		if err := notifyUser(&models.Notification{
+			UserID:  user.ID,
+			DiaryID: &diary.ID,
+			Type:    "mention",
+			Title:   "มีผู้กล่าวถึงคุณ",
+			Message: fmt.Sprintf("%s กล่าวถึงคุณใน%s", userDisplayName(author), where),
+			Data:    dataJSON,
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// recordMentionsAsync บันทึกการกล่าวถึงโดยไม่หน่วง response
+func recordMentionsAsync(diary models.Diary, commentID *uint, authorID uint, text string) {
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("Recovered from panic in mentions for diary %d: %v", diary.ID, r)
+			}
+		}()
+		if err := recordMentions(diary, commentID, authorID, text); err != nil {
+			log.Printf("Recording mentions failed for diary %d: %v", diary.ID, err)
+		}
+	}()
+}
+
+// GetMyMentions คืนค่าการกล่าวถึงผู้ใช้ปัจจุบัน เรียงจากใหม่ไปเก่า
+func GetMyMentions(c *fiber.Ctx) error {
+	user := currentUser(c)
+	if user == nil {
+		return errUnauthorized("Unauthorized")
+	}
+
+	var query MentionQuery
+	if err := bindQuery(c, &query); err != nil {
+		return err
+	}
+	page, limit := pageParams(query.Page, query.Limit)
+
+	dbQuery := database.DB.Model(&models.Mention{}).Where("user_id = ?", user.ID)
+
+	var total int64
+	if err := dbQuery.Count(&total).Error; err != nil {
+		return errInternal(err, "Failed to count mentions")
+	}
+
+	mentions := []models.Mention{}
+	if err := dbQuery.
+		Preload("Author").
+		Order("created_at DESC, id DESC").
+		Offset((page - 1) * limit).
+		Limit(limit).
+		Find(&mentions).Error; err != nil {
+		return errInternal(err, "Failed to query mentions")
+	}
+
+	return respondMeta(c, fiber.StatusOK, "Mentions retrieved successfully", mentions, fiber.Map{
+		"count": len(mentions),
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+}
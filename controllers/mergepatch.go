@@ -0,0 +1,105 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"gofiber-auth/validation"
+
+	"gorm.io/datatypes"
+)
+
+// patchField อธิบายฟิลด์หนึ่งที่อนุญาตให้แก้ไขผ่าน JSON Merge Patch (RFC 7386)
+type patchField struct {
+	Column   string
+	Aliases  []string
+	Roles    []string
+	Nullable bool
+	Decode   func(raw json.RawMessage) (interface{}, bool)
+	Rules    string
+}
+
+func (f patchField) allows(role string) bool {
+	for _, r := range f.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// applyMergePatch แปลง body แบบ merge patch ให้เป็น map ของคอลัมน์ที่ role นี้แก้ไขได้เท่านั้น
+// ฟิลด์ที่ไม่รู้จักหรือไม่ได้รับอนุญาตจะถูกปฏิเสธทั้งหมด ไม่ถูกข้ามไปเงียบ ๆ
+func applyMergePatch(body []byte, fields []patchField, role string) (map[string]interface{}, validation.Errors, error) {
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(body, &doc); err != nil || doc == nil {
+		return nil, nil, errors.New("merge patch body must be a JSON object")
+	}
+
+	byName := make(map[string]patchField)
+	for _, f := range fields {
+		byName[f.Column] = f
+		for _, alias := range f.Aliases {
+			byName[alias] = f
+		}
+	}
+
+	var errs validation.Errors
+	updates := make(map[string]interface{})
+	for key, raw := range doc {
+		field, known := byName[key]
+		if !known || !field.allows(role) {
+			errs = append(errs, validation.Forbidden(key))
+			continue
+		}
+
+		if string(raw) == "null" {
+			if !field.Nullable {
+				errs = append(errs, validation.Invalid(key, "cannot be null", "ไม่สามารถเป็นค่าว่างได้"))
+				continue
+			}
+			updates[field.Column] = nil
+			continue
+		}
+
+		value, ok := field.Decode(raw)
+		if !ok {
+			errs = append(errs, validation.Invalid(key, "has an invalid type", "ชนิดข้อมูลไม่ถูกต้อง"))
+			continue
+		}
+		if field.Rules != "" {
+			if fieldErrs := validation.Var(key, value, field.Rules); len(fieldErrs) > 0 {
+				errs = append(errs, fieldErrs...)
+				continue
+			}
+		}
+		updates[field.Column] = value
+	}
+
+	if len(errs) > 0 {
+		return nil, errs, nil
+	}
+	return updates, nil, nil
+}
+
+func decodePatchString(raw json.RawMessage) (interface{}, bool) {
+	var s string
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return nil, false
+	}
+	return s, true
+}
+
+func decodePatchBool(raw json.RawMessage) (interface{}, bool) {
+	var b bool
+	if err := json.Unmarshal(raw, &b); err != nil {
+		return nil, false
+	}
+	return b, true
+}
+
+func decodePatchJSON(raw json.RawMessage) (interface{}, bool) {
+	if !json.Valid(raw) {
+		return nil, false
+	}
+	return datatypes.JSON(raw), true
+}
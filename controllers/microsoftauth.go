@@ -0,0 +1,127 @@
+package controllers
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// errMicrosoftNotConfigured หมายถึงยังไม่ได้ตั้ง MICROSOFT_TENANT_ID หรือ MICROSOFT_CLIENT_ID
+var errMicrosoftNotConfigured = errors.New("microsoft login is not configured")
+
+// microsoftClaims คือ claim ใน id_token ของ Microsoft identity platform ที่ใช้ระบุผู้ใช้
+type microsoftClaims struct {
+	Email             string `json:"email"`
+	PreferredUsername string `json:"preferred_username"`
+	Name              string `json:"name"`
+	jwt.RegisteredClaims
+}
+
+// microsoftKeySet เก็บกุญแจสาธารณะจาก JWKS ของ tenant ไว้ใช้ซ้ำ
+// จะโหลดใหม่เมื่อกุญแจเก่ากว่า 24 ชั่วโมง หรือเมื่อพบ kid ที่ไม่รู้จัก (ไม่เกินนาทีละครั้ง)
+type microsoftKeySet struct {
+	mu        sync.Mutex
+	keys      map[string]*rsa.PublicKey
+	fetchedAt time.Time
+}
+
+var (
+	microsoftKeys       = &microsoftKeySet{}
+	microsoftHTTPClient = &http.Client{Timeout: 10 * time.Second}
+)
+
+func (s *microsoftKeySet) key(tenant, kid string) (*rsa.PublicKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if key, ok := s.keys[kid]; ok && time.Since(s.fetchedAt) < 24*time.Hour {
+		return key, nil
+	}
+	if time.Since(s.fetchedAt) >= time.Minute {
+		keys, err := fetchMicrosoftKeys(tenant)
+		if err != nil {
+			return nil, err
+		}
+		s.keys, s.fetchedAt = keys, time.Now()
+	}
+	if key, ok := s.keys[kid]; ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+// fetchMicrosoftKeys โหลดกุญแจ RSA จาก JWKS endpoint ของ tenant
+func fetchMicrosoftKeys(tenant string) (map[string]*rsa.PublicKey, error) {
+	resp, err := microsoftHTTPClient.Get("https://login.microsoftonline.com/" + url.PathEscape(tenant) + "/discovery/v2.0/keys")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch microsoft keys: %s", resp.Status)
+	}
+
+	var set struct {
+		Keys []struct {
+			Kid string `json:"kid"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" || k.Kid == "" {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			continue
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil || len(e) == 0 || len(e) > 4 {
+			continue
+		}
+		keys[k.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	}
+	return keys, nil
+}
+
+// verifyMicrosoftIDToken ตรวจลายเซ็นของ id_token กับ JWKS ของ tenant ตรวจ issuer และ audience ต้องเป็น client ID ของแอป
+// รองรับเฉพาะ tenant ที่ระบุใน MICROSOFT_TENANT_ID ไม่รับ common หรือ organizations
+func verifyMicrosoftIDToken(raw string) (*microsoftClaims, error) {
+	tenant := os.Getenv("MICROSOFT_TENANT_ID")
+	clientID := os.Getenv("MICROSOFT_CLIENT_ID")
+	if tenant == "" || clientID == "" {
+		return nil, errMicrosoftNotConfigured
+	}
+
+	claims := &microsoftClaims{}
+	_, err := jwt.ParseWithClaims(raw, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return microsoftKeys.key(tenant, kid)
+	},
+		jwt.WithValidMethods([]string{"RS256"}),
+		jwt.WithIssuer("https://login.microsoftonline.com/"+tenant+"/v2.0"),
+		jwt.WithAudience(clientID),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
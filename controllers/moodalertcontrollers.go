@@ -0,0 +1,371 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"gofiber-auth/database"
+	"gofiber-auth/models"
+	"log"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// กฎการแจ้งเตือนอารมณ์เชิงลบ ใช้เป็นค่า MoodAlert.Rule และ data.rule ของ Notification
+const (
+	AlertConsecutiveNegative = "consecutive_negative"
+	AlertMoodDrop            = "mood_drop"
+	AlertSilentAfterNegative = "silent_after_negative"
+)
+
+// moodAlertLookback คือช่วงย้อนหลังสูงสุดที่ใช้โหลดบันทึกมาประเมินกฎ
+const moodAlertLookback = 90 * 24 * time.Hour
+
+type MoodAlertSettingRequest struct {
+	Enabled             *bool   `json:"enabled"`
+	NegativeScoreMax    int     `json:"negative_score_max" validate:"required,gte=1,lte=10"`
+	ConsecutiveNegative int     `json:"consecutive_negative" validate:"required,gte=2,lte=30"`
+	BaselineDays        int     `json:"baseline_days" validate:"required,gte=3,lte=60"`
+	RecentEntries       int     `json:"recent_entries" validate:"required,gte=1,lte=14"`
+	DropThreshold       float64 `json:"drop_threshold" validate:"required,gte=0.1,lte=10"`
+	SilenceDays         int     `json:"silence_days" validate:"required,gte=1,lte=60"`
+	CooldownDays        int     `json:"cooldown_days" validate:"required,gte=1,lte=60"`
+}
+
+// scoredEntry คือบันทึกหนึ่งรายการพร้อมคะแนนอารมณ์จาก mood_scales
+type scoredEntry struct {
+	DiaryID   uint
+	DiaryDate time.Time
+	Score     int
+}
+
+// moodAlertFinding คือผลการประเมินกฎที่ต้องแจ้งเตือน
+type moodAlertFinding struct {
+	Rule    string
+	DiaryID *uint
+	Title   string
+	Message string
+	Details map[string]interface{}
+}
+
+func defaultMoodAlertSetting(advisorID uint) models.MoodAlertSetting {
+	return models.MoodAlertSetting{
+		AdvisorID:           advisorID,
+		Enabled:             true,
+		NegativeScoreMax:    2,
+		ConsecutiveNegative: 3,
+		BaselineDays:        14,
+		RecentEntries:       3,
+		DropThreshold:       1.5,
+		SilenceDays:         3,
+		CooldownDays:        7,
+	}
+}
+
+// moodAlertSettingFor คืนค่าเกณฑ์ของอาจารย์ หรือค่าเริ่มต้นหากยังไม่เคยตั้งค่า
+func moodAlertSettingFor(advisorID uint) (models.MoodAlertSetting, error) {
+	var setting models.MoodAlertSetting
+	result := database.DB.Where("advisor_id = ?", advisorID).Limit(1).Find(&setting)
+	if result.Error != nil {
+		return setting, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return defaultMoodAlertSetting(advisorID), nil
+	}
+	return setting, nil
+}
+
+// userDisplayName คืนค่าชื่อที่ใช้แสดงในการแจ้งเตือน
+func userDisplayName(user models.User) string {
+	if user.Name != nil && *user.Name != "" {
+		return *user.Name
+	}
+	return "ไม่ระบุชื่อ"
+}
+
+// notifyUser บันทึกการแจ้งเตือนแล้วส่งผ่าน SSE หากผู้รับเชื่อมต่ออยู่
+func notifyUser(notif *models.Notification) error {
+	if err := database.DB.Create(notif).Error; err != nil {
+		return err
+	}
+	SendNotificationToAdvisor(notif.UserID, *notif)
+	return nil
+}
+
+// loadScoredEntries โหลดบันทึกของนิสิตที่มีคะแนนอารมณ์ เรียงจากใหม่ไปเก่า
+// ไม่รวมบันทึกส่วนตัว เพราะอาจารย์ไม่มีสิทธิ์เห็นทั้งอารมณ์และ DiaryID ของบันทึกเหล่านั้น
+func loadScoredEntries(studentID uint, since time.Time) ([]scoredEntry, error) {
+	var entries []scoredEntry
+	err := database.DB.Model(&models.Diary{}).
+		Joins("JOIN mood_scales ON mood_scales.mood_key = diaries.status").
+		Where("diaries.student_id = ? AND diaries.diary_date >= ?", studentID, since.Format(dateLayout)).
+		Where("diaries.is_shared <> ?", "private").
+		Select("diaries.id AS diary_id, diaries.diary_date AS diary_date, mood_scales.score AS score").
+		Order("diaries.diary_date DESC, diaries.id DESC").
+		Scan(&entries).Error
+	return entries, err
+}
+
+func averageScore(entries []scoredEntry) float64 {
+	if len(entries) == 0 {
+		return 0
+	}
+	total := 0
+	for _, e := range entries {
+		total += e.Score
+	}
+	return float64(total) / float64(len(entries))
+}
+
+// evaluateMoodRules ประเมินกฎทั้งหมดกับบันทึกที่เรียงจากใหม่ไปเก่า
+// กฎ silent_after_negative ประเมินเฉพาะรอบของ scheduler เพราะตอนสร้างบันทึกนิสิตเพิ่งเขียน
+func evaluateMoodRules(entries []scoredEntry, setting models.MoodAlertSetting, now time.Time, includeSilence bool) []moodAlertFinding {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	var findings []moodAlertFinding
+	latest := entries[0]
+	isNegative := func(e scoredEntry) bool { return e.Score <= setting.NegativeScoreMax }
+
+	if n := setting.ConsecutiveNegative; n > 0 && len(entries) >= n {
+		streak := true
+		for _, e := range entries[:n] {
+			if !isNegative(e) {
+				streak = false
+				break
+			}
+		}
+		if streak {
+			findings = append(findings, moodAlertFinding{
+				Rule:    AlertConsecutiveNegative,
+				DiaryID: &latest.DiaryID,
+				Title:   "แจ้งเตือน: นิสิตมีอารมณ์เชิงลบต่อเนื่อง",
+				Message: fmt.Sprintf("บันทึก %d รายการล่าสุดมีอารมณ์เชิงลบติดต่อกัน", n),
+				Details: map[string]interface{}{"entries": n},
+			})
+		}
+	}
+
+	if r := setting.RecentEntries; r > 0 && len(entries) > r {
+		recent := entries[:r]
+		cutoff := recent[r-1].DiaryDate.AddDate(0, 0, -setting.BaselineDays)
+		var baseline []scoredEntry
+		for _, e := range entries[r:] {
+			if e.DiaryDate.Before(cutoff) {
+				break
+			}
+			baseline = append(baseline, e)
+		}
+		if len(baseline) >= r {
+			recentAvg, baselineAvg := averageScore(recent), averageScore(baseline)
+			if drop := baselineAvg - recentAvg; drop >= setting.DropThreshold {
+				findings = append(findings, moodAlertFinding{
+					Rule:    AlertMoodDrop,
+					DiaryID: &latest.DiaryID,
+					Title:   "แจ้งเตือน: อารมณ์ของนิสิตลดลงอย่างชัดเจน",
+					Message: fmt.Sprintf("คะแนนอารมณ์เฉลี่ยลดลงจาก %.1f เป็น %.1f", baselineAvg, recentAvg),
+					Details: map[string]interface{}{
+						"baseline_average": baselineAvg,
+						"recent_average":   recentAvg,
+						"drop":             drop,
+					},
+				})
+			}
+		}
+	}
+
+	if includeSilence && isNegative(latest) {
+		silentDays := int(now.Sub(latest.DiaryDate).Hours() / 24)
+		if silentDays >= setting.SilenceDays {
+			findings = append(findings, moodAlertFinding{
+				Rule:    AlertSilentAfterNegative,
+				DiaryID: &latest.DiaryID,
+				Title:   "แจ้งเตือน: นิสิตหยุดเขียนบันทึกหลังมีอารมณ์เชิงลบ",
+				Message: fmt.Sprintf("ไม่มีบันทึกใหม่มา %d วันหลังบันทึกที่มีอารมณ์เชิงลบ", silentDays),
+				Details: map[string]interface{}{"silent_days": silentDays},
+			})
+		}
+	}
+
+	return findings
+}
+
+// alreadyAlerted ตรวจสอบว่ากฎนี้เคยแจ้งเตือนสำหรับบันทึกเดียวกัน หรือภายในช่วง cooldown แล้วหรือไม่
+func alreadyAlerted(advisorID, studentID uint, finding moodAlertFinding, cooldownDays int, now time.Time) (bool, error) {
+	var count int64
+	query := database.DB.Model(&models.MoodAlert{}).
+		Where("advisor_id = ? AND student_id = ? AND rule = ?", advisorID, studentID, finding.Rule)
+	if finding.DiaryID != nil {
+		query = query.Where("(diary_id = ? OR created_at >= ?)", *finding.DiaryID, now.AddDate(0, 0, -cooldownDays))
+	} else {
+		query = query.Where("created_at >= ?", now.AddDate(0, 0, -cooldownDays))
+	}
+	err := query.Count(&count).Error
+	return count > 0, err
+}
+
+// EvaluateMoodAlerts ประเมินกฎการแจ้งเตือนของนิสิตตามเกณฑ์ของอาจารย์ที่ปรึกษาแต่ละคน
+// และส่งการแจ้งเตือนความสำคัญสูงเมื่อพบเงื่อนไข
+func EvaluateMoodAlerts(studentID uint, includeSilence bool) error {
+	var links []models.StudentAdvisor
+	if err := database.DB.Where("student_id = ?", studentID).Find(&links).Error; err != nil {
+		return err
+	}
+	if len(links) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	entries, err := loadScoredEntries(studentID, now.Add(-moodAlertLookback))
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+
+	var student models.User
+	if err := database.DB.First(&student, studentID).Error; err != nil {
+		return err
+	}
+
+	for _, link := range links {
+		setting, err := moodAlertSettingFor(link.AdvisorID)
+		if err != nil {
+			return err
+		}
+		if !setting.Enabled {
+			continue
+		}
+
+		for _, finding := range evaluateMoodRules(entries, setting, now, includeSilence) {
+			alerted, err := alreadyAlerted(link.AdvisorID, studentID, finding, setting.CooldownDays, now)
+			if err != nil {
+				return err
+			}
+			if alerted {
+				continue
+			}
+
+			data := map[string]interface{}{
+				"rule":       finding.Rule,
+				"student_id": studentID,
+				"details":    finding.Details,
+			}
+			dataJSON, _ := json.Marshal(data)
+
+			notif := models.Notification{
+				UserID:   link.AdvisorID,
+				DiaryID:  finding.DiaryID,
+				Type:     "mood_alert",
+				Priority: "high",
+				Title:    finding.Title,
+				Message:  fmt.Sprintf("%s: %s", userDisplayName(student), finding.Message),
+				Data:     dataJSON,
+			}
+			if err := notifyUser(&notif); err != nil {
+				return err
+			}
+
+			alert := models.MoodAlert{
+				AdvisorID:      link.AdvisorID,
+				StudentID:      studentID,
+				Rule:           finding.Rule,
+				DiaryID:        finding.DiaryID,
+				NotificationID: &notif.ID,
+			}
+			if err := database.DB.Create(&alert).Error; err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// evaluateMoodAlertsAsync ประเมินกฎหลังสร้างบันทึกโดยไม่หน่วง response
+func evaluateMoodAlertsAsync(studentID uint) {
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("Recovered from panic in mood alerts for student %d: %v", studentID, r)
+			}
+		}()
+		if err := EvaluateMoodAlerts(studentID, false); err != nil {
+			log.Printf("Mood alert evaluation failed for student %d: %v", studentID, err)
+		}
+	}()
+}
+
+// sweepMoodAlerts ประเมินกฎของนิสิตทุกคนที่มีอาจารย์ที่ปรึกษา ใช้โดย scheduler
+func sweepMoodAlerts() error {
+	var studentIDs []uint
+	if err := database.DB.Model(&models.StudentAdvisor{}).Distinct("student_id").Pluck("student_id", &studentIDs).Error; err != nil {
+		return err
+	}
+	for _, id := range studentIDs {
+		if err := EvaluateMoodAlerts(id, true); err != nil {
+			log.Printf("Mood alert evaluation failed for student %d: %v", id, err)
+		}
+	}
+	return nil
+}
+
+func GetMoodAlertSetting(c *fiber.Ctx) error {
+	user := currentUser(c)
+	if user == nil {
+		return errUnauthorized("Unauthorized")
+	}
+
+	setting, err := moodAlertSettingFor(user.ID)
+	if err != nil {
+		return errInternal(err, "Failed to load alert settings")
+	}
+
+	return respond(c, fiber.StatusOK, "Alert settings retrieved successfully", setting)
+}
+
+func UpdateMoodAlertSetting(c *fiber.Ctx) error {
+	user := currentUser(c)
+	if user == nil {
+		return errUnauthorized("Unauthorized")
+	}
+
+	var input MoodAlertSettingRequest
+	if err := bindBody(c, &input); err != nil {
+		return err
+	}
+
+	setting, err := moodAlertSettingFor(user.ID)
+	if err != nil {
+		return errInternal(err, "Failed to load alert settings")
+	}
+
+	// ไม่ระบุ enabled ให้คงค่าเดิม เพื่อไม่ให้การแก้เกณฑ์อื่นปิดการแจ้งเตือนโดยไม่ตั้งใจ
+	if input.Enabled != nil {
+		setting.Enabled = *input.Enabled
+	}
+	setting.NegativeScoreMax = input.NegativeScoreMax
+	setting.ConsecutiveNegative = input.ConsecutiveNegative
+	setting.BaselineDays = input.BaselineDays
+	setting.RecentEntries = input.RecentEntries
+	setting.DropThreshold = input.DropThreshold
+	setting.SilenceDays = input.SilenceDays
+	setting.CooldownDays = input.CooldownDays
+
+	if setting.ID == 0 {
+		err = database.DB.Create(&setting).Error
+		// Create ข้ามค่า false ของคอลัมน์ที่มีค่าเริ่มต้นเป็น true จึงต้องอัปเดตซ้ำ
+		if err == nil && !setting.Enabled {
+			err = database.DB.Model(&setting).Update("enabled", false).Error
+		}
+	} else {
+		err = database.DB.Save(&setting).Error
+	}
+	if err != nil {
+		return errInternal(err, "Failed to save alert settings")
+	}
+
+	return respond(c, fiber.StatusOK, "Alert settings updated successfully", setting)
+}
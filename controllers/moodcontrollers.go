@@ -3,76 +3,270 @@ package controllers
 import (
 	"gofiber-auth/database"
 	"gofiber-auth/models"
+	"sync"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 )
 
-func GetMoodByAdvisor(c *fiber.Ctx) error {
-	advisorIDStr := c.Query("advisor_id")
-	startDateStr := c.Query("startDate")
-	endDateStr := c.Query("endDate")
+// moodScaleTTL คือระยะเวลาที่เก็บระดับอารมณ์ไว้ในหน่วยความจำ ก่อนโหลดจากฐานข้อมูลใหม่
+const moodScaleTTL = time.Minute
+
+var (
+	moodScaleMu       sync.RWMutex
+	moodScaleCache    []models.MoodScale
+	moodScaleLoadedAt time.Time
+)
+
+// loadMoodScale คืนค่าระดับอารมณ์ทั้งหมดเรียงตาม SortOrder
+func loadMoodScale() ([]models.MoodScale, error) {
+	moodScaleMu.RLock()
+	if moodScaleCache != nil && time.Since(moodScaleLoadedAt) < moodScaleTTL {
+		scale := moodScaleCache
+		moodScaleMu.RUnlock()
+		return scale, nil
+	}
+	moodScaleMu.RUnlock()
+
+	var scale []models.MoodScale
+	if err := database.DB.Order("sort_order ASC, id ASC").Find(&scale).Error; err != nil {
+		return nil, err
+	}
+
+	moodScaleMu.Lock()
+	moodScaleCache = scale
+	moodScaleLoadedAt = time.Now()
+	moodScaleMu.Unlock()
 
-	if advisorIDStr == "" {
-		return c.Status(400).JSON(fiber.Map{"error": "AdvisorID is required"})
+	return scale, nil
+}
+
+func invalidateMoodScale() {
+	moodScaleMu.Lock()
+	moodScaleCache = nil
+	moodScaleMu.Unlock()
+}
+
+// isMoodKey ตรวจสอบว่า key อยู่ในระดับอารมณ์ที่ตั้งค่าไว้หรือไม่
+func isMoodKey(key string) bool {
+	scale, err := loadMoodScale()
+	if err != nil {
+		return false
 	}
+	for _, m := range scale {
+		if m.Key == key {
+			return true
+		}
+	}
+	return false
+}
 
-	var startDate, endDate time.Time
-	var err error
-	if startDateStr != "" {
-		startDate, err = time.Parse("2006-01-02", startDateStr)
-		if err != nil {
-			return c.Status(400).JSON(fiber.Map{"error": "Invalid startDate format"})
+// defaultMoodKey คืนค่า key ของระดับอารมณ์ที่ใช้เมื่อนิสิตไม่ได้เลือก
+func defaultMoodKey() string {
+	scale, err := loadMoodScale()
+	if err != nil || len(scale) == 0 {
+		return "neutral"
+	}
+	for _, m := range scale {
+		if m.IsDefault {
+			return m.Key
 		}
 	}
-	if endDateStr != "" {
-		endDate, err = time.Parse("2006-01-02", endDateStr)
-		if err != nil {
-			return c.Status(400).JSON(fiber.Map{"error": "Invalid endDate format"})
+	return scale[len(scale)/2].Key
+}
+
+type MoodQuery struct {
+	AdvisorID uint   `query:"advisor_id"`
+	StartDate string `query:"startDate" validate:"omitempty,date"`
+	EndDate   string `query:"endDate" validate:"omitempty,date"`
+}
+
+type MoodScaleRequest struct {
+	Key       string `json:"key" validate:"required,max=50"`
+	LabelTH   string `json:"label_th" validate:"required,max=100"`
+	LabelEN   string `json:"label_en" validate:"required,max=100"`
+	Score     int    `json:"score"`
+	Color     string `json:"color" validate:"max=20"`
+	Emoji     string `json:"emoji" validate:"max=20"`
+	SortOrder int    `json:"sort_order"`
+	IsDefault bool   `json:"is_default"`
+}
+
+// GetMoodByAdvisor นับจำนวนบันทึกแยกตามอารมณ์ของนิสิตในการดูแล ไม่รวมบันทึกส่วนตัว
+func GetMoodByAdvisor(c *fiber.Ctx) error {
+	user := currentUser(c)
+	if user == nil {
+		return errUnauthorized("Unauthorized")
+	}
+
+	var query MoodQuery
+	if err := bindQuery(c, &query); err != nil {
+		return err
+	}
+	advisorID, err := scopedAdvisorID(user, query.AdvisorID)
+	if err != nil {
+		return err
+	}
+	query.AdvisorID = advisorID
+
+	scale, err := loadMoodScale()
+	if err != nil {
+		return errInternal(err, "Failed to load mood scale")
+	}
+
+	counts := make(map[string]int64)
+	for _, m := range scale {
+		counts[m.Key] = 0
+	}
+
+	dbQuery := database.DB.Model(&models.Diary{}).
+		Joins("JOIN student_advisors ON student_advisors.student_id = diaries.student_id").
+		Where("student_advisors.advisor_id = ? AND diaries.is_shared <> ?", query.AdvisorID, "private")
+	if query.StartDate != "" {
+		dbQuery = dbQuery.Where("diaries.diary_date >= ?", query.StartDate)
+	}
+	if query.EndDate != "" {
+		dbQuery = dbQuery.Where("diaries.diary_date <= ?", query.EndDate)
+	}
+
+	var rows []struct {
+		Status string
+		Total  int64
+	}
+	if err := dbQuery.
+		Select("diaries.status AS status, COUNT(*) AS total").
+		Group("diaries.status").
+		Scan(&rows).Error; err != nil {
+		return errInternal(err, "Failed to query moods")
+	}
+
+	for _, row := range rows {
+		if _, ok := counts[row.Status]; ok {
+			counts[row.Status] = row.Total
 		}
 	}
 
-	var studentAdvisors []models.StudentAdvisor
-	if err := database.DB.Where("advisor_id = ?", advisorIDStr).Find(&studentAdvisors).Error; err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	return respondMeta(c, fiber.StatusOK, "Mood counts retrieved successfully", counts, fiber.Map{
+		"scale": scale,
+	})
+}
+
+func GetMoodScale(c *fiber.Ctx) error {
+	scale, err := loadMoodScale()
+	if err != nil {
+		return errInternal(err, "Failed to load mood scale")
+	}
+
+	return respond(c, fiber.StatusOK, "Mood scale retrieved successfully", scale)
+}
+
+func CreateMoodScale(c *fiber.Ctx) error {
+	var input MoodScaleRequest
+	if err := bindBody(c, &input); err != nil {
+		return err
+	}
+
+	var existing int64
+	if err := database.DB.Model(&models.MoodScale{}).Where("mood_key = ?", input.Key).Count(&existing).Error; err != nil {
+		return errInternal(err, "Failed to check mood scale")
+	}
+	if existing > 0 {
+		return errConflict("Mood key already exists")
 	}
 
-	if len(studentAdvisors) == 0 {
-		statusesList := []string{"veryHappy", "happy", "neutral", "stressed", "burnedOut"}
-		counts := make(map[string]int)
-		for _, s := range statusesList {
-			counts[s] = 0
+	mood := models.MoodScale{
+		Key:       input.Key,
+		LabelTH:   input.LabelTH,
+		LabelEN:   input.LabelEN,
+		Score:     input.Score,
+		Color:     input.Color,
+		Emoji:     input.Emoji,
+		SortOrder: input.SortOrder,
+		IsDefault: input.IsDefault,
+	}
+
+	tx := database.DB.Begin()
+	if mood.IsDefault {
+		if err := tx.Model(&models.MoodScale{}).Where("is_default = ?", true).Update("is_default", false).Error; err != nil {
+			tx.Rollback()
+			return errInternal(err, "Failed to update default mood")
 		}
-		return c.JSON(counts)
+	}
+	if err := tx.Create(&mood).Error; err != nil {
+		tx.Rollback()
+		return errInternal(err, "Failed to create mood scale")
+	}
+	if err := tx.Commit().Error; err != nil {
+		return errInternal(err, "Failed to commit transaction")
 	}
 
-	studentIDs := make([]uint, len(studentAdvisors))
-	for i, sa := range studentAdvisors {
-		studentIDs[i] = sa.StudentID
+	invalidateMoodScale()
+	return respond(c, fiber.StatusCreated, "Mood scale created successfully", mood)
+}
+
+func UpdateMoodScale(c *fiber.Ctx) error {
+	id := c.Params("id")
+	var mood models.MoodScale
+	if err := database.DB.First(&mood, id).Error; err != nil {
+		return errLookup(err, "Mood scale not found")
 	}
 
-	dbQuery := database.DB.Model(&models.Diary{}).Where("student_id IN ?", studentIDs)
-	if !startDate.IsZero() {
-		dbQuery = dbQuery.Where("diary_date >= ?", startDate)
+	var input MoodScaleRequest
+	if err := bindBody(c, &input); err != nil {
+		return err
 	}
-	if !endDate.IsZero() {
-		dbQuery = dbQuery.Where("diary_date <= ?", endDate)
+
+	// key ถูกอ้างอิงจาก Diary.Status จึงไม่อนุญาตให้เปลี่ยน
+	if input.Key != mood.Key {
+		return errBadRequest("Mood key cannot be changed")
 	}
 
-	var statuses []string
-	if err := dbQuery.Pluck("status", &statuses).Error; err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	tx := database.DB.Begin()
+	if input.IsDefault && !mood.IsDefault {
+		if err := tx.Model(&models.MoodScale{}).Where("is_default = ?", true).Update("is_default", false).Error; err != nil {
+			tx.Rollback()
+			return errInternal(err, "Failed to update default mood")
+		}
 	}
+	if err := tx.Model(&mood).Updates(map[string]interface{}{
+		"label_th":   input.LabelTH,
+		"label_en":   input.LabelEN,
+		"score":      input.Score,
+		"color":      input.Color,
+		"emoji":      input.Emoji,
+		"sort_order": input.SortOrder,
+		"is_default": input.IsDefault,
+	}).Error; err != nil {
+		tx.Rollback()
+		return errInternal(err, "Failed to update mood scale")
+	}
+	if err := tx.Commit().Error; err != nil {
+		return errInternal(err, "Failed to commit transaction")
+	}
+
+	invalidateMoodScale()
+	return respond(c, fiber.StatusOK, "Mood scale updated successfully", mood)
+}
 
-	statusesList := []string{"veryHappy", "happy", "neutral", "stressed", "burnedOut"}
-	counts := make(map[string]int)
-	for _, s := range statusesList {
-		counts[s] = 0
+func DeleteMoodScale(c *fiber.Ctx) error {
+	id := c.Params("id")
+	var mood models.MoodScale
+	if err := database.DB.First(&mood, id).Error; err != nil {
+		return errLookup(err, "Mood scale not found")
+	}
+
+	var inUse int64
+	if err := database.DB.Model(&models.Diary{}).Where("status = ?", mood.Key).Count(&inUse).Error; err != nil {
+		return errInternal(err, "Failed to check mood usage")
+	}
+	if inUse > 0 {
+		return errConflict("Mood is used by existing diaries and cannot be deleted")
 	}
 
-	for _, status := range statuses {
-		counts[status]++
+	if err := database.DB.Delete(&mood).Error; err != nil {
+		return errInternal(err, "Failed to delete mood scale")
 	}
 
-	return c.JSON(counts)
+	invalidateMoodScale()
+	return respond(c, fiber.StatusOK, "Mood scale deleted successfully", nil)
 }
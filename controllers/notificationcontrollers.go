@@ -55,7 +55,7 @@ func init() {
 func AdvisorSSE(c *fiber.Ctx) error {
 	advisorID, err := strconv.ParseUint(c.Query("advisor_id"), 10, 32)
 	if err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "invalid advisor_id"})
+		return errBadRequest("invalid advisor_id")
 	}
 
 	var advisorExists bool
@@ -63,7 +63,7 @@ func AdvisorSSE(c *fiber.Ctx) error {
 		Select("count(*) > 0").
 		Where("id = ? AND role = ?", advisorID, "advisor").
 		Find(&advisorExists).Error; err != nil || !advisorExists {
-		return c.Status(404).JSON(fiber.Map{"error": "advisor not found"})
+		return errNotFound("advisor not found")
 	}
 
 	c.Set("Access-Control-Allow-Origin", os.Getenv("CORS_ALLOW_ORIGINS"))
@@ -200,12 +200,11 @@ func SendNotificationToAdvisor(advisorID uint, notification models.Notification)
 }
 
 func GetUnreadNotifications(c *fiber.Ctx) error {
-	advisorID := c.Query("advisor_id")
-	if advisorID == "" {
-		return c.Status(400).JSON(fiber.Map{
-			"error": "advisor_id is required",
-		})
+	var query AdvisorQuery
+	if err := bindQuery(c, &query); err != nil {
+		return err
 	}
+	advisorID := query.AdvisorID
 
 	var notifications []models.Notification
 	result := database.DB.
@@ -217,25 +216,20 @@ func GetUnreadNotifications(c *fiber.Ctx) error {
 		Find(&notifications)
 
 	if result.Error != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"error": result.Error.Error(),
-		})
+		return errInternal(result.Error, "Failed to retrieve notifications")
 	}
 
-	return c.JSON(fiber.Map{
-		"message": "Unread notifications retrieved successfully",
-		"data":    notifications,
-		"count":   len(notifications),
+	return respondMeta(c, fiber.StatusOK, "Unread notifications retrieved successfully", notifications, fiber.Map{
+		"count": len(notifications),
 	})
 }
 
 func GetAllNotifications(c *fiber.Ctx) error {
-	advisorID := c.Query("advisor_id")
-	if advisorID == "" {
-		return c.Status(400).JSON(fiber.Map{
-			"error": "advisor_id is required",
-		})
+	var query AdvisorQuery
+	if err := bindQuery(c, &query); err != nil {
+		return err
 	}
+	advisorID := query.AdvisorID
 
 	page := c.QueryInt("page", 1)
 	limit := c.QueryInt("limit", 20)
@@ -262,62 +256,42 @@ func GetAllNotifications(c *fiber.Ctx) error {
 
 	if result.Error != nil {
 		if result.Error == gorm.ErrRecordNotFound {
-			return c.JSON(fiber.Map{
-				"message": "No notifications found",
-				"data":    []models.Notification{},
-				"count":   0,
-				"total":   0,
-				"page":    page,
-				"limit":   limit,
+			return respondMeta(c, fiber.StatusOK, "No notifications found", []models.Notification{}, fiber.Map{
+				"count": 0,
+				"total": 0,
+				"page":  page,
+				"limit": limit,
 			})
 		}
-		return c.Status(500).JSON(fiber.Map{
-			"error": result.Error.Error(),
-		})
+		return errInternal(result.Error, "Failed to retrieve notifications")
 	}
 
 	var total int64
 	if err := database.DB.Model(&models.Notification{}).Where("user_id = ?", advisorID).Count(&total).Error; err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"error": "Failed to count notifications",
-		})
+		return errInternal(err, "Failed to count notifications")
 	}
 
-	return c.JSON(fiber.Map{
-		"message": "Notifications retrieved successfully",
-		"data":    notifications,
-		"count":   len(notifications),
-		"total":   total,
-		"page":    page,
-		"limit":   limit,
+	return respondMeta(c, fiber.StatusOK, "Notifications retrieved successfully", notifications, fiber.Map{
+		"count": len(notifications),
+		"total": total,
+		"page":  page,
+		"limit": limit,
 	})
 }
 
 func MarkNotificationAsRead(c *fiber.Ctx) error {
 	notificationID := c.Params("id")
 	if notificationID == "" {
-		return c.Status(400).JSON(fiber.Map{
-			"error": "notification ID is required",
-		})
+		return errBadRequest("notification ID is required")
 	}
 
 	var notification models.Notification
-	if result := database.DB.Preload("User").First(&notification, notificationID); result.Error != nil {
-		if result.Error == gorm.ErrRecordNotFound {
-			return c.Status(404).JSON(fiber.Map{
-				"error": "Notification not found",
-			})
-		}
-		return c.Status(500).JSON(fiber.Map{
-			"error": result.Error.Error(),
-		})
+	if err := database.DB.Preload("User").First(&notification, notificationID).Error; err != nil {
+		return errLookup(err, "Notification not found")
 	}
 
 	if notification.IsRead {
-		return c.JSON(fiber.Map{
-			"message": "Notification already marked as read",
-			"data":    notification,
-		})
+		return respond(c, fiber.StatusOK, "Notification already marked as read", notification)
 	}
 
 	tx := database.DB.Begin()
@@ -331,16 +305,11 @@ func MarkNotificationAsRead(c *fiber.Ctx) error {
 
 	if err := tx.Save(&notification).Error; err != nil {
 		tx.Rollback()
-		return c.Status(500).JSON(fiber.Map{
-			"error":   "Failed to update notification",
-			"details": err.Error(),
-		})
+		return errInternal(err, "Failed to update notification")
 	}
 
 	if err := tx.Commit().Error; err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"error": "Failed to commit transaction",
-		})
+		return errInternal(err, "Failed to commit transaction")
 	}
 
 	readEvent := models.Notification{
@@ -350,20 +319,15 @@ func MarkNotificationAsRead(c *fiber.Ctx) error {
 	}
 	SendNotificationToAdvisor(notification.UserID, readEvent)
 
-	return c.JSON(fiber.Map{
-		"message": "Notification marked as read",
-		"ok":      true,
-		"data":    notification,
-	})
+	return respond(c, fiber.StatusOK, "Notification marked as read", notification)
 }
 
 func MarkAllNotificationsAsRead(c *fiber.Ctx) error {
-	advisorID := c.Query("advisor_id")
-	if advisorID == "" {
-		return c.Status(400).JSON(fiber.Map{
-			"error": "advisor_id is required",
-		})
+	var query AdvisorQuery
+	if err := bindQuery(c, &query); err != nil {
+		return err
 	}
+	advisorID := query.AdvisorID
 
 	tx := database.DB.Begin()
 	defer func() {
@@ -378,62 +342,42 @@ func MarkAllNotificationsAsRead(c *fiber.Ctx) error {
 
 	if result.Error != nil {
 		tx.Rollback()
-		return c.Status(500).JSON(fiber.Map{
-			"error": result.Error.Error(),
-		})
+		return errInternal(result.Error, "Failed to update notifications")
 	}
 
 	if err := tx.Commit().Error; err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"error": "Failed to commit transaction",
-		})
+		return errInternal(err, "Failed to commit transaction")
 	}
 
-	return c.JSON(fiber.Map{
-		"message": fmt.Sprintf("Marked %d notifications as read", result.RowsAffected),
-		"count":   result.RowsAffected,
+	return respondMeta(c, fiber.StatusOK, fmt.Sprintf("Marked %d notifications as read", result.RowsAffected), nil, fiber.Map{
+		"count": result.RowsAffected,
 	})
 }
 
 func DeleteNotification(c *fiber.Ctx) error {
 	notificationID := c.Params("id")
 	if notificationID == "" {
-		return c.Status(400).JSON(fiber.Map{
-			"error": "notification ID is required",
-		})
+		return errBadRequest("notification ID is required")
 	}
 
 	var notification models.Notification
-	if result := database.DB.First(&notification, notificationID); result.Error != nil {
-		if result.Error == gorm.ErrRecordNotFound {
-			return c.Status(404).JSON(fiber.Map{
-				"error": "Notification not found",
-			})
-		}
-		return c.Status(500).JSON(fiber.Map{
-			"error": result.Error.Error(),
-		})
+	if err := database.DB.First(&notification, notificationID).Error; err != nil {
+		return errLookup(err, "Notification not found")
 	}
 
 	if err := database.DB.Delete(&notification).Error; err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"error":   "Failed to delete notification",
-			"details": err.Error(),
-		})
+		return errInternal(err, "Failed to delete notification")
 	}
 
-	return c.JSON(fiber.Map{
-		"message": "Notification deleted successfully",
-	})
+	return respond(c, fiber.StatusOK, "Notification deleted successfully", nil)
 }
 
 func GetNotificationCount(c *fiber.Ctx) error {
-	advisorID := c.Query("advisor_id")
-	if advisorID == "" {
-		return c.Status(400).JSON(fiber.Map{
-			"error": "advisor_id is required",
-		})
+	var query AdvisorQuery
+	if err := bindQuery(c, &query); err != nil {
+		return err
 	}
+	advisorID := query.AdvisorID
 
 	var unreadCount int64
 	result := database.DB.Model(&models.Notification{}).
@@ -441,9 +385,7 @@ func GetNotificationCount(c *fiber.Ctx) error {
 		Count(&unreadCount)
 
 	if result.Error != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"error": result.Error.Error(),
-		})
+		return errInternal(result.Error, "Failed to count notifications")
 	}
 
 	var totalCount int64
@@ -451,8 +393,7 @@ func GetNotificationCount(c *fiber.Ctx) error {
 		Where("user_id = ?", advisorID).
 		Count(&totalCount)
 
-	return c.JSON(fiber.Map{
-		"message":      "Notification count retrieved successfully",
+	return respond(c, fiber.StatusOK, "Notification count retrieved successfully", fiber.Map{
 		"unread_count": unreadCount,
 		"total_count":  totalCount,
 	})
@@ -470,9 +411,7 @@ func GetActiveConnections(c *fiber.Ctx) error {
 		}
 	}
 
-	return c.JSON(fiber.Map{
-		"message":     "Active connections retrieved",
-		"connections": connections,
-		"count":       len(connections),
+	return respondMeta(c, fiber.StatusOK, "Active connections retrieved", connections, fiber.Map{
+		"count": len(connections),
 	})
 }
@@ -0,0 +1,292 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"gofiber-auth/database"
+	"gofiber-auth/models"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ชนิดของรายการที่รับ reaction ได้ ใช้เป็นค่า Reaction.TargetType
+const (
+	ReactionTargetDiary   = "diary"
+	ReactionTargetComment = "comment"
+)
+
+// defaultReactionEmojis คือชุด emoji เริ่มต้น เช่น 👀 สำหรับ "อ่านแล้ว"
+const defaultReactionEmojis = "👀,❤️,👍,🙏,💪"
+
+type ReactionRequest struct {
+	TargetType string `json:"target_type" validate:"required,oneof=diary comment"`
+	TargetID   uint   `json:"target_id" validate:"required"`
+	Emoji      string `json:"emoji" validate:"required,max=32"`
+}
+
+type ReactionTargetQuery struct {
+	TargetType string `query:"target_type" validate:"required,oneof=diary comment"`
+	TargetID   uint   `query:"target_id" validate:"required"`
+}
+
+// reactionEmojis คืนค่าชุด emoji ที่อนุญาต ตั้งค่าผ่าน REACTION_EMOJIS โดยคั่นด้วยจุลภาค
+func reactionEmojis() []string {
+	value := os.Getenv("REACTION_EMOJIS")
+	if strings.TrimSpace(value) == "" {
+		value = defaultReactionEmojis
+	}
+	var emojis []string
+	for _, emoji := range strings.Split(value, ",") {
+		if emoji = strings.TrimSpace(emoji); emoji != "" {
+			emojis = append(emojis, emoji)
+		}
+	}
+	return emojis
+}
+
+func allowedReaction(emoji string) bool {
+	for _, allowed := range reactionEmojis() {
+		if emoji == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// reactionCounts คืนค่าจำนวน reaction แยกตาม emoji ของแต่ละรายการ
+func reactionCounts(targetType string, ids []uint) (map[uint]map[string]int64, error) {
+	counts := make(map[uint]map[string]int64, len(ids))
+	if len(ids) == 0 {
+		return counts, nil
+	}
+
+	var rows []struct {
+		TargetID uint
+		Emoji    string
+		Total    int64
+	}
+	if err := database.DB.Model(&models.Reaction{}).
+		Where("target_type = ? AND target_id IN ?", targetType, ids).
+		Select("target_id, emoji, COUNT(*) AS total").
+		Group("target_id, emoji").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		if counts[row.TargetID] == nil {
+			counts[row.TargetID] = map[string]int64{}
+		}
+		counts[row.TargetID][row.Emoji] = row.Total
+	}
+	return counts, nil
+}
+
+// attachDiaryReactions เติมจำนวน reaction ให้บันทึกก่อนส่ง response
+func attachDiaryReactions(diaries []models.Diary) error {
+	ids := make([]uint, len(diaries))
+	for i, diary := range diaries {
+		ids[i] = diary.ID
+	}
+	counts, err := reactionCounts(ReactionTargetDiary, ids)
+	if err != nil {
+		return err
+	}
+	for i := range diaries {
+		diaries[i].Reactions = counts[diaries[i].ID]
+	}
+	return nil
+}
+
+// attachCommentReactions เติมจำนวน reaction ให้ความคิดเห็นทุก node
+func attachCommentReactions(nodes map[uint]*CommentNode) error {
+	ids := make([]uint, 0, len(nodes))
+	for id := range nodes {
+		ids = append(ids, id)
+	}
+	counts, err := reactionCounts(ReactionTargetComment, ids)
+	if err != nil {
+		return err
+	}
+	for id, node := range nodes {
+		node.Reactions = counts[id]
+	}
+	return nil
+}
+
+// reactionDiary คืนค่าบันทึกที่เป็นเจ้าของรายการ และตรวจว่าผู้ใช้มองเห็นบันทึกนั้น
+func reactionDiary(user *models.User, targetType string, targetID uint) (models.Diary, error) {
+	var diary models.Diary
+	diaryID := targetID
+	if targetType == ReactionTargetComment {
+		var comment models.Comment
+		if err := database.DB.First(&comment, targetID).Error; err != nil {
+			return diary, errLookup(err, "Comment not found")
+		}
+		diaryID = comment.DiaryID
+	}
+	if err := database.DB.First(&diary, diaryID).Error; err != nil {
+		return diary, errLookup(err, "Diary not found")
+	}
+
+	visible, err := canViewDiary(user, diary)
+	if err != nil {
+		return diary, errInternal(err, "Failed to check diary access")
+	}
+	if !visible {
+		return diary, errForbidden("You cannot react to this diary")
+	}
+	return diary, nil
+}
+
+// notifyReaction แจ้งนิสิตเจ้าของบันทึกเมื่อมี reaction ใหม่จากผู้อื่น
+func notifyReaction(diary models.Diary, reaction models.Reaction, user *models.User) error {
+	if reaction.UserID == diary.StudentID {
+		return nil
+	}
+
+	data := map[string]interface{}{
+		"diary_date":  diary.DiaryDate.Format(dateLayout),
+		"target_type": reaction.TargetType,
+		"target_id":   reaction.TargetID,
+		"emoji":       reaction.Emoji,
+	}
+	dataJSON, _ := json.Marshal(data)
+
+	target := "บันทึก"
+	if reaction.TargetType == ReactionTargetComment {
+		target = "ความคิดเห็น"
+	}
+
+	return notifyUser(&models.Notification{
+		UserID:  diary.StudentID,
+		DiaryID: &diary.ID,
+		Type:    "reaction",
+		Title:   "มีผู้ตอบสนองต่อบันทึกของคุณ",
+		Message: fmt.Sprintf("%s ตอบสนอง %s ต่อ%sของคุณ", userDisplayName(*user), reaction.Emoji, target),
+		Data:    dataJSON,
+	})
+}
+
+func GetReactionEmojis(c *fiber.Ctx) error {
+	return respond(c, fiber.StatusOK, "Reaction emojis retrieved successfully", reactionEmojis())
+}
+
+// GetReactions คืนค่ารายชื่อผู้ที่ตอบสนองต่อรายการ พร้อมจำนวนแยกตาม emoji
+func GetReactions(c *fiber.Ctx) error {
+	user := currentUser(c)
+	if user == nil {
+		return errUnauthorized("Unauthorized")
+	}
+
+	var query ReactionTargetQuery
+	if err := bindQuery(c, &query); err != nil {
+		return err
+	}
+	if _, err := reactionDiary(user, query.TargetType, query.TargetID); err != nil {
+		return err
+	}
+
+	reactions := []models.Reaction{}
+	if err := database.DB.
+		Preload("User").
+		Where("target_type = ? AND target_id = ?", query.TargetType, query.TargetID).
+		Order("created_at ASC").
+		Find(&reactions).Error; err != nil {
+		return errInternal(err, "Failed to query reactions")
+	}
+
+	counts := map[string]int64{}
+	for _, reaction := range reactions {
+		counts[reaction.Emoji]++
+	}
+
+	return respondMeta(c, fiber.StatusOK, "Reactions retrieved successfully", reactions, fiber.Map{
+		"count":  len(reactions),
+		"counts": counts,
+	})
+}
+
+// SetReaction เพิ่ม reaction หรือเปลี่ยน emoji หากผู้ใช้เคยตอบสนองต่อรายการนี้แล้ว
+// แจ้งเตือนเจ้าของบันทึกเฉพาะ reaction ใหม่
+func SetReaction(c *fiber.Ctx) error {
+	user := currentUser(c)
+	if user == nil {
+		return errUnauthorized("Unauthorized")
+	}
+
+	var req ReactionRequest
+	if err := bindBody(c, &req); err != nil {
+		return err
+	}
+	if !allowedReaction(req.Emoji) {
+		return errBadRequest("Emoji is not allowed").WithTH("ไม่สามารถใช้ emoji นี้ได้")
+	}
+
+	diary, err := reactionDiary(user, req.TargetType, req.TargetID)
+	if err != nil {
+		return err
+	}
+
+	var reaction models.Reaction
+	result := database.DB.
+		Where("user_id = ? AND target_type = ? AND target_id = ?", user.ID, req.TargetType, req.TargetID).
+		Limit(1).
+		Find(&reaction)
+	if result.Error != nil {
+		return errInternal(result.Error, "Failed to query reaction")
+	}
+
+	if result.RowsAffected > 0 {
+		if reaction.Emoji != req.Emoji {
+			if err := database.DB.Model(&reaction).Update("emoji", req.Emoji).Error; err != nil {
+				return errInternal(err, "Failed to update reaction")
+			}
+			reaction.Emoji = req.Emoji
+		}
+		return respond(c, fiber.StatusOK, "Reaction updated successfully", reaction)
+	}
+
+	reaction = models.Reaction{
+		UserID:     user.ID,
+		TargetType: req.TargetType,
+		TargetID:   req.TargetID,
+		Emoji:      req.Emoji,
+	}
+	if err := database.DB.Create(&reaction).Error; err != nil {
+		return errInternal(err, "Failed to create reaction")
+	}
+
+	if err := notifyReaction(diary, reaction, user); err != nil {
+		log.Printf("[%s] Failed to notify reaction %d: %v", requestID(c), reaction.ID, err)
+	}
+
+	return respond(c, fiber.StatusCreated, "Reaction added successfully", reaction)
+}
+
+func DeleteReaction(c *fiber.Ctx) error {
+	user := currentUser(c)
+	if user == nil {
+		return errUnauthorized("Unauthorized")
+	}
+
+	var query ReactionTargetQuery
+	if err := bindQuery(c, &query); err != nil {
+		return err
+	}
+
+	result := database.DB.
+		Where("user_id = ? AND target_type = ? AND target_id = ?", user.ID, query.TargetType, query.TargetID).
+		Delete(&models.Reaction{})
+	if result.Error != nil {
+		return errInternal(result.Error, "Failed to delete reaction")
+	}
+	if result.RowsAffected == 0 {
+		return errNotFound("Reaction not found")
+	}
+
+	return respond(c, fiber.StatusOK, "Reaction removed successfully", nil)
+}
@@ -0,0 +1,269 @@
+package controllers
+
+import (
+	"bufio"
+	"database/sql"
+	"fmt"
+	"gofiber-auth/database"
+	"gofiber-auth/export"
+	"gofiber-auth/models"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// reportFlushEvery คือจำนวนแถวที่เขียนก่อน flush ไปยัง client ระหว่าง stream
+const reportFlushEvery = 200
+
+type ReportQuery struct {
+	Format    string `query:"format" validate:"omitempty,oneof=csv xlsx"`
+	AdvisorID uint   `query:"advisor_id"`
+	GroupID   uint   `query:"group_id"`
+	StartDate string `query:"startDate" validate:"required,date"`
+	EndDate   string `query:"endDate" validate:"required,date"`
+}
+
+// reportSQL คือคำสั่ง SQL ของรายงานหนึ่งฉบับพร้อมหัวตาราง
+type reportSQL struct {
+	Header []string
+	Query  string
+	Args   []interface{}
+}
+
+// reportScope คืนค่า JOIN ที่จำกัดนิสิตตามอาจารย์ที่ปรึกษาหรือกลุ่ม
+// ทั้งสองกรณีใช้ alias "scope" ที่มีคอลัมน์ advisor_id เพื่อให้รายงานอ้างถึงอาจารย์ได้แบบเดียวกัน
+func reportScope(q ReportQuery) (string, []interface{}) {
+	if q.GroupID != 0 {
+		return "JOIN student_groups sg ON sg.student_id = users.id AND sg.group_id = ? " +
+			"JOIN `groups` scope ON scope.id = sg.group_id", []interface{}{q.GroupID}
+	}
+	return "JOIN student_advisors scope ON scope.student_id = users.id AND scope.advisor_id = ?", []interface{}{q.AdvisorID}
+}
+
+// reports คือรายงานที่ export ได้ แต่ละรายการสร้าง SQL จาก query ของ request
+var reports = map[string]func(q ReportQuery) (reportSQL, error){
+	"mood-counts":    moodCountsReport,
+	"weekly-entries": weeklyEntriesReport,
+	"response-times": responseTimesReport,
+}
+
+// moodCountsReport คือจำนวนบันทึกของนิสิตแต่ละคนแยกตามระดับอารมณ์ในช่วงภาคการศึกษา ไม่รวมบันทึกส่วนตัว
+func moodCountsReport(q ReportQuery) (reportSQL, error) {
+	scale, err := loadMoodScale()
+	if err != nil {
+		return reportSQL{}, err
+	}
+
+	header := []string{"student_id", "name", "email"}
+	var selects []string
+	var args []interface{}
+	for _, m := range scale {
+		header = append(header, fmt.Sprintf("%s (%s)", m.LabelTH, m.Key))
+		selects = append(selects, "SUM(CASE WHEN diaries.status = ? THEN 1 ELSE 0 END)")
+		args = append(args, m.Key)
+	}
+	header = append(header, "total", "average_score")
+
+	scopeJoin, scopeArgs := reportScope(q)
+	args = append(args, scopeArgs...)
+	args = append(args, q.StartDate, q.EndDate)
+
+	query := "SELECT users.id, users.name, users.email, " + strings.Join(append(selects, ""), ", ") +
+		"COUNT(diaries.id), ROUND(AVG(mood_scales.score), 2) " +
+		"FROM users " + scopeJoin + " " +
+		"LEFT JOIN diaries ON diaries.student_id = users.id AND diaries.diary_date BETWEEN ? AND ? AND diaries.is_shared <> 'private' " +
+		"LEFT JOIN mood_scales ON mood_scales.mood_key = diaries.status " +
+		"WHERE users.role = 'student' " +
+		"GROUP BY users.id, users.name, users.email " +
+		"ORDER BY users.name, users.id"
+
+	return reportSQL{Header: header, Query: query, Args: args}, nil
+}
+
+// weeklyEntriesReport คือจำนวนบันทึกของนิสิตแต่ละคนรายสัปดาห์ (สัปดาห์เริ่มวันจันทร์)
+func weeklyEntriesReport(q ReportQuery) (reportSQL, error) {
+	scopeJoin, scopeArgs := reportScope(q)
+	week := periodExpr("week")
+
+	query := "SELECT users.id, users.name, " + week + " AS week_start, COUNT(*) " +
+		"FROM users " + scopeJoin + " " +
+		"JOIN diaries ON diaries.student_id = users.id AND diaries.diary_date BETWEEN ? AND ? " +
+		"WHERE users.role = 'student' " +
+		"GROUP BY users.id, users.name, week_start " +
+		"ORDER BY users.name, users.id, week_start"
+
+	return reportSQL{
+		Header: []string{"student_id", "name", "week_start", "entries"},
+		Query:  query,
+		Args:   append(scopeArgs, q.StartDate, q.EndDate),
+	}, nil
+}
+
+// responseTimesReport คือเวลาที่อาจารย์ใช้ก่อนแสดงความคิดเห็นแรกในบันทึกที่อาจารย์มองเห็นได้
+// บันทึกที่ยังไม่มีความคิดเห็นจะมีช่อง first_response_at และ response_hours ว่าง
+func responseTimesReport(q ReportQuery) (reportSQL, error) {
+	scopeJoin, scopeArgs := reportScope(q)
+
+	query := "SELECT diaries.id, users.name, advisors.name, " +
+		"DATE_FORMAT(diaries.diary_date, '%Y-%m-%d'), " +
+		"DATE_FORMAT(diaries.created_at, '%Y-%m-%d %H:%i'), " +
+		"DATE_FORMAT(MIN(comments.created_at), '%Y-%m-%d %H:%i'), " +
+		"ROUND(TIMESTAMPDIFF(MINUTE, diaries.created_at, MIN(comments.created_at)) / 60, 2) " +
+		"FROM users " + scopeJoin + " " +
+		"JOIN users advisors ON advisors.id = scope.advisor_id " +
+		"JOIN diaries ON diaries.student_id = users.id AND diaries.diary_date BETWEEN ? AND ? AND diaries.is_shared <> 'private' " +
+		"LEFT JOIN comments ON comments.diary_id = diaries.id AND comments.author_id = scope.advisor_id " +
+		"WHERE users.role = 'student' " +
+		"GROUP BY diaries.id, users.name, advisors.name, diaries.diary_date, diaries.created_at " +
+		"ORDER BY diaries.diary_date, diaries.id"
+
+	return reportSQL{
+		Header: []string{"diary_id", "student", "advisor", "diary_date", "posted_at", "first_response_at", "response_hours"},
+		Query:  query,
+		Args:   append(scopeArgs, q.StartDate, q.EndDate),
+	}, nil
+}
+
+// reportCell แปลงค่าจากฐานข้อมูลเป็นชนิดที่ export.Writer รู้จัก
+// ตัวเลขทศนิยมจาก MySQL มาเป็น []byte จึงต้องแปลงตามชนิดของคอลัมน์
+func reportCell(v interface{}, dbType string) interface{} {
+	b, ok := v.([]byte)
+	if !ok {
+		return v
+	}
+	switch dbType {
+	case "DECIMAL", "FLOAT", "DOUBLE":
+		if f, err := strconv.ParseFloat(string(b), 64); err == nil {
+			return f
+		}
+	case "INT", "BIGINT", "TINYINT", "SMALLINT", "MEDIUMINT", "UNSIGNED INT", "UNSIGNED BIGINT":
+		if n, err := strconv.ParseInt(string(b), 10, 64); err == nil {
+			return n
+		}
+	}
+	return string(b)
+}
+
+func streamReport(rows *sql.Rows, w *bufio.Writer, format export.Format, name string, header []string) error {
+	defer rows.Close()
+
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return err
+	}
+
+	out, err := export.NewWriter(format, w, name)
+	if err != nil {
+		return err
+	}
+
+	headerRow := make([]interface{}, len(header))
+	for i, h := range header {
+		headerRow[i] = h
+	}
+	if err := out.WriteRow(headerRow); err != nil {
+		return err
+	}
+
+	values := make([]interface{}, len(types))
+	ptrs := make([]interface{}, len(types))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+
+	for n := 1; rows.Next(); n++ {
+		if err := rows.Scan(ptrs...); err != nil {
+			return err
+		}
+		row := make([]interface{}, len(values))
+		for i, v := range values {
+			row[i] = reportCell(v, types[i].DatabaseTypeName())
+		}
+		if err := out.WriteRow(row); err != nil {
+			return err
+		}
+		if n%reportFlushEvery == 0 {
+			if err := w.Flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// ExportReport ส่งรายงานเป็น CSV หรือ XLSX แบบ stream ทีละแถวจากฐานข้อมูล
+func ExportReport(c *fiber.Ctx) error {
+	name := c.Params("report")
+	build, ok := reports[name]
+	if !ok {
+		return errNotFound("Report not found")
+	}
+
+	user := currentUser(c)
+	if user == nil {
+		return errUnauthorized("Unauthorized")
+	}
+
+	var query ReportQuery
+	if err := bindQuery(c, &query); err != nil {
+		return err
+	}
+	if query.AdvisorID != 0 && query.GroupID != 0 {
+		return errBadRequest("Only one of advisor_id or group_id may be given").WithTH("ระบุ advisor_id หรือ group_id ได้เพียงอย่างเดียว")
+	}
+	// อาจารย์ export ได้เฉพาะนิสิตของตนเองหรือกลุ่มที่ตนดูแล
+	if query.GroupID != 0 {
+		var group models.Group
+		if err := database.DB.First(&group, query.GroupID).Error; err != nil {
+			return errLookup(err, "Group not found")
+		}
+		if !canViewGroup(user, group) {
+			return errForbidden("You can only export groups you manage")
+		}
+	} else {
+		advisorID, err := scopedAdvisorID(user, query.AdvisorID)
+		if err != nil {
+			return err
+		}
+		query.AdvisorID = advisorID
+	}
+	if query.StartDate > query.EndDate {
+		return errBadRequest("startDate must not be after endDate").WithTH("วันเริ่มต้นต้องไม่อยู่หลังวันสิ้นสุด")
+	}
+
+	format := export.Format(query.Format)
+	if format == "" {
+		format = export.CSV
+	}
+
+	report, err := build(query)
+	if err != nil {
+		return errInternal(err, "Failed to build report")
+	}
+
+	rows, err := database.DB.Raw(report.Query, report.Args...).Rows()
+	if err != nil {
+		return errInternal(err, "Failed to query report")
+	}
+
+	filename := fmt.Sprintf("%s_%s_%s.%s", name, query.StartDate, query.EndDate, format)
+	c.Set(fiber.HeaderContentType, format.ContentType())
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s"`, filename))
+
+	requestID := requestID(c)
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		if err := streamReport(rows, w, format, name, report.Header); err != nil {
+			log.Printf("[%s] export %s failed: %v", requestID, name, err)
+		}
+	})
+	return nil
+}
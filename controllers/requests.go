@@ -0,0 +1,52 @@
+package controllers
+
+import (
+	"gofiber-auth/validation"
+	"reflect"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// AdvisorQuery คือ query string ที่ต้องระบุ advisor_id ซึ่งใช้ร่วมกันหลาย endpoint
+type AdvisorQuery struct {
+	AdvisorID uint `query:"advisor_id" validate:"required"`
+}
+
+// ScopedAdvisorQuery คือ advisor_id ของ endpoint ที่ใช้ scopedAdvisorID ไม่ระบุหมายถึงตนเอง
+type ScopedAdvisorQuery struct {
+	AdvisorID uint `query:"advisor_id"`
+}
+
+// IDQuery คือ query string ที่ต้องระบุ id ของรายการ
+type IDQuery struct {
+	ID uint `query:"id" validate:"required"`
+}
+
+func init() {
+	validation.RegisterRule("mood", func(v reflect.Value, _ string) bool {
+		return v.Kind() == reflect.String && isMoodKey(v.String())
+	}, "must be a configured mood key", "สถานะอารมณ์ไม่ถูกต้อง")
+}
+
+// bindBody อ่าน JSON body ลงใน DTO แล้วตรวจสอบตาม tag `validate`
+func bindBody(c *fiber.Ctx, dto interface{}) error {
+	if err := c.BodyParser(dto); err != nil {
+		return errBadRequest("Cannot parse JSON").WithTH("รูปแบบข้อมูลไม่ถูกต้อง")
+	}
+	return validateRequest(dto)
+}
+
+// bindQuery อ่าน query string ลงใน DTO แล้วตรวจสอบตาม tag `validate`
+func bindQuery(c *fiber.Ctx, dto interface{}) error {
+	if err := c.QueryParser(dto); err != nil {
+		return errBadRequest("Invalid query parameters").WithTH("พารามิเตอร์ไม่ถูกต้อง")
+	}
+	return validateRequest(dto)
+}
+
+func validateRequest(dto interface{}) error {
+	if errs := validation.Struct(dto); len(errs) > 0 {
+		return errValidation(errs)
+	}
+	return nil
+}
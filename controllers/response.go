@@ -0,0 +1,179 @@
+package controllers
+
+import (
+	"errors"
+	"gofiber-auth/validation"
+	"log"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// รหัสข้อผิดพลาดที่ client ใช้ตรวจสอบได้โดยไม่ต้องอ่านข้อความ
+const (
+	CodeBadRequest           = "BAD_REQUEST"
+	CodeValidationFailed     = "VALIDATION_FAILED"
+	CodeUnauthorized         = "UNAUTHORIZED"
+	CodeForbidden            = "FORBIDDEN"
+	CodeNotFound             = "NOT_FOUND"
+	CodeConflict             = "CONFLICT"
+	CodePreconditionFailed   = "PRECONDITION_FAILED"
+	CodePreconditionRequired = "PRECONDITION_REQUIRED"
+	CodeInternal             = "INTERNAL_ERROR"
+)
+
+// Envelope คือรูปแบบ response เดียวของทุก endpoint
+type Envelope struct {
+	OK      bool        `json:"ok"`
+	Message string      `json:"message,omitempty"`
+	Data    interface{} `json:"data,omitempty"`
+	Meta    fiber.Map   `json:"meta,omitempty"`
+	Error   *ErrorBody  `json:"error,omitempty"`
+}
+
+// ErrorBody คือรายละเอียดข้อผิดพลาดใน Envelope
+type ErrorBody struct {
+	Code      string            `json:"code"`
+	Message   string            `json:"message"`
+	MessageTH string            `json:"message_th,omitempty"`
+	Fields    validation.Errors `json:"fields,omitempty"`
+	Details   interface{}       `json:"details,omitempty"`
+	RequestID string            `json:"request_id,omitempty"`
+}
+
+// APIError คือข้อผิดพลาดที่ handler คืนค่าให้ ErrorHandler แปลงเป็น Envelope
+// Err เก็บสาเหตุภายในไว้สำหรับ log เท่านั้น ไม่ถูกส่งให้ client
+type APIError struct {
+	Status    int
+	Code      string
+	Message   string
+	MessageTH string
+	Fields    validation.Errors
+	Details   interface{}
+	Err       error
+}
+
+func (e *APIError) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+// WithTH เพิ่มข้อความภาษาไทยให้ข้อผิดพลาด
+func (e *APIError) WithTH(message string) *APIError {
+	e.MessageTH = message
+	return e
+}
+
+func errBadRequest(message string) *APIError {
+	return &APIError{Status: fiber.StatusBadRequest, Code: CodeBadRequest, Message: message}
+}
+
+func errUnauthorized(message string) *APIError {
+	return &APIError{Status: fiber.StatusUnauthorized, Code: CodeUnauthorized, Message: message}
+}
+
+func errForbidden(message string) *APIError {
+	return &APIError{Status: fiber.StatusForbidden, Code: CodeForbidden, Message: message}
+}
+
+func errNotFound(message string) *APIError {
+	return &APIError{Status: fiber.StatusNotFound, Code: CodeNotFound, Message: message}
+}
+
+func errConflict(message string) *APIError {
+	return &APIError{Status: fiber.StatusConflict, Code: CodeConflict, Message: message}
+}
+
+func errValidation(fields validation.Errors) *APIError {
+	return &APIError{
+		Status:    fiber.StatusUnprocessableEntity,
+		Code:      CodeValidationFailed,
+		Message:   "Validation failed",
+		MessageTH: "ข้อมูลไม่ถูกต้อง",
+		Fields:    fields,
+	}
+}
+
+func errInternal(err error, message string) *APIError {
+	return &APIError{Status: fiber.StatusInternalServerError, Code: CodeInternal, Message: message, Err: err}
+}
+
+// errLookup แยกกรณีไม่พบข้อมูลออกจากข้อผิดพลาดของฐานข้อมูล
+func errLookup(err error, notFoundMessage string) *APIError {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return errNotFound(notFoundMessage)
+	}
+	return errInternal(err, "Database error")
+}
+
+// respond ส่ง response สำเร็จในรูปแบบ Envelope
+func respond(c *fiber.Ctx, status int, message string, data interface{}) error {
+	return c.Status(status).JSON(Envelope{OK: true, Message: message, Data: data})
+}
+
+// respondMeta ส่ง response สำเร็จพร้อมข้อมูลประกอบ เช่น จำนวนและการแบ่งหน้า
+func respondMeta(c *fiber.Ctx, status int, message string, data interface{}, meta fiber.Map) error {
+	return c.Status(status).JSON(Envelope{OK: true, Message: message, Data: data, Meta: meta})
+}
+
+func requestID(c *fiber.Ctx) string {
+	id, _ := c.Locals("requestid").(string)
+	return id
+}
+
+// ErrorHandler แปลงทุกข้อผิดพลาดที่ handler คืนค่าเป็น Envelope และ log ข้อผิดพลาดภายในพร้อม request ID
+func ErrorHandler(c *fiber.Ctx, err error) error {
+	var apiErr *APIError
+	var fiberErr *fiber.Error
+
+	switch {
+	case errors.As(err, &apiErr):
+	case errors.As(err, &fiberErr):
+		apiErr = &APIError{Status: fiberErr.Code, Code: codeForStatus(fiberErr.Code), Message: fiberErr.Message}
+	default:
+		apiErr = errInternal(err, "Internal server error")
+	}
+
+	if apiErr.Status >= fiber.StatusInternalServerError {
+		log.Printf("[%s] %s %s: %v", requestID(c), c.Method(), c.Path(), apiErr)
+	}
+
+	return c.Status(apiErr.Status).JSON(Envelope{
+		OK: false,
+		Error: &ErrorBody{
+			Code:      apiErr.Code,
+			Message:   apiErr.Message,
+			MessageTH: apiErr.MessageTH,
+			Fields:    apiErr.Fields,
+			Details:   apiErr.Details,
+			RequestID: requestID(c),
+		},
+	})
+}
+
+func codeForStatus(status int) string {
+	switch status {
+	case fiber.StatusBadRequest:
+		return CodeBadRequest
+	case fiber.StatusUnauthorized:
+		return CodeUnauthorized
+	case fiber.StatusForbidden:
+		return CodeForbidden
+	case fiber.StatusNotFound:
+		return CodeNotFound
+	case fiber.StatusConflict:
+		return CodeConflict
+	case fiber.StatusPreconditionFailed:
+		return CodePreconditionFailed
+	case fiber.StatusPreconditionRequired:
+		return CodePreconditionRequired
+	case fiber.StatusUnprocessableEntity:
+		return CodeValidationFailed
+	}
+	if status >= fiber.StatusInternalServerError {
+		return CodeInternal
+	}
+	return CodeBadRequest
+}
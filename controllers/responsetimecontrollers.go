@@ -0,0 +1,132 @@
+package controllers
+
+import (
+	"gofiber-auth/database"
+	"gofiber-auth/models"
+	"math"
+	"sort"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// responseTimeWindowDays คือช่วงวันย้อนหลังที่ dashboard ใช้คำนวณเวลาตอบกลับ
+const responseTimeWindowDays = 30
+
+type ResponseTimeQuery struct {
+	AdvisorID uint   `query:"advisor_id"`
+	StartDate string `query:"startDate" validate:"omitempty,date"`
+	EndDate   string `query:"endDate" validate:"omitempty,date"`
+}
+
+// ResponseTimeStats คือสถิติเวลาที่อาจารย์ใช้ก่อนแสดงความคิดเห็นแรกในบันทึกของนิสิต
+// นับเฉพาะบันทึกที่อาจารย์มองเห็นได้ เวลาเป็นชั่วโมงนับจาก Diary.CreatedAt
+type ResponseTimeStats struct {
+	AdvisorID   uint     `json:"advisor_id"`
+	Name        *string  `json:"name"`
+	Diaries     int64    `json:"diaries"`
+	Answered    int64    `json:"answered"`
+	Unanswered  int64    `json:"unanswered"`
+	MedianHours *float64 `json:"median_hours"`
+	P90Hours    *float64 `json:"p90_hours"`
+}
+
+// percentile คืนค่าเปอร์เซ็นไทล์ p (0-1) ของข้อมูลที่เรียงแล้ว โดยประมาณค่าเชิงเส้นระหว่างสองตำแหน่ง
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 1 {
+		return sorted[0]
+	}
+	pos := p * float64(len(sorted)-1)
+	lower := int(math.Floor(pos))
+	upper := int(math.Ceil(pos))
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(pos-float64(lower))
+}
+
+func roundHours(seconds float64) *float64 {
+	hours := math.Round(seconds/3600*100) / 100
+	return &hours
+}
+
+// responseTimeStats คำนวณสถิติเวลาตอบกลับของอาจารย์ทุกคน หรือเฉพาะ advisorID หากไม่เป็น 0
+// ระหว่างวันที่ start ถึง end (รูปแบบ YYYY-MM-DD ตาม diary_date)
+// MySQL ไม่มีฟังก์ชัน percentile จึงดึงเวลาของแต่ละบันทึกมาคำนวณ median และ p90 ในโปรแกรม
+func responseTimeStats(advisorID uint, start, end string) ([]ResponseTimeStats, error) {
+	query := database.DB.Model(&models.Diary{}).
+		Joins("JOIN student_advisors ON student_advisors.student_id = diaries.student_id").
+		Joins("JOIN users advisors ON advisors.id = student_advisors.advisor_id").
+		Joins("LEFT JOIN comments ON comments.diary_id = diaries.id AND comments.author_id = student_advisors.advisor_id").
+		Where("diaries.diary_date BETWEEN ? AND ? AND diaries.is_shared <> ?", start, end, "private")
+	if advisorID != 0 {
+		query = query.Where("student_advisors.advisor_id = ?", advisorID)
+	}
+
+	var rows []struct {
+		AdvisorID uint
+		Name      *string
+		Seconds   *float64
+	}
+	if err := query.
+		Select("student_advisors.advisor_id AS advisor_id, advisors.name AS name, " +
+			"TIMESTAMPDIFF(SECOND, diaries.created_at, MIN(comments.created_at)) AS seconds").
+		Group("student_advisors.advisor_id, advisors.name, diaries.id, diaries.created_at").
+		Order("student_advisors.advisor_id ASC").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	stats := []ResponseTimeStats{}
+	var durations []float64
+	flush := func() {
+		if len(stats) == 0 {
+			return
+		}
+		last := &stats[len(stats)-1]
+		if len(durations) > 0 {
+			sort.Float64s(durations)
+			last.MedianHours = roundHours(percentile(durations, 0.5))
+			last.P90Hours = roundHours(percentile(durations, 0.9))
+		}
+		durations = durations[:0]
+	}
+
+	for _, row := range rows {
+		if len(stats) == 0 || stats[len(stats)-1].AdvisorID != row.AdvisorID {
+			flush()
+			stats = append(stats, ResponseTimeStats{AdvisorID: row.AdvisorID, Name: row.Name})
+		}
+		current := &stats[len(stats)-1]
+		current.Diaries++
+		if row.Seconds == nil {
+			current.Unanswered++
+			continue
+		}
+		current.Answered++
+		durations = append(durations, math.Max(*row.Seconds, 0))
+	}
+	flush()
+
+	return stats, nil
+}
+
+// GetResponseTimeStats แสดงเวลาตอบกลับของอาจารย์ทุกคนในช่วงวันที่ ค่าเริ่มต้นคือ 30 วันล่าสุด
+func GetResponseTimeStats(c *fiber.Ctx) error {
+	var query ResponseTimeQuery
+	if err := bindQuery(c, &query); err != nil {
+		return err
+	}
+
+	start, end, err := MoodSeriesQuery{StartDate: query.StartDate, EndDate: query.EndDate}.dateRange()
+	if err != nil {
+		return err
+	}
+
+	stats, err := responseTimeStats(query.AdvisorID, start.Format(dateLayout), end.Format(dateLayout))
+	if err != nil {
+		return errInternal(err, "Failed to compute response times")
+	}
+
+	return respondMeta(c, fiber.StatusOK, "Response times retrieved successfully", stats, fiber.Map{
+		"start_date": start.Format(dateLayout),
+		"end_date":   end.Format(dateLayout),
+		"count":      len(stats),
+	})
+}
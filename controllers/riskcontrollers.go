@@ -0,0 +1,387 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"gofiber-auth/database"
+	"gofiber-auth/models"
+	"gofiber-auth/risk"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// riskKeywordTTL คือระยะเวลาที่เก็บรายการคำเสี่ยงไว้ในหน่วยความจำ ก่อนโหลดจากฐานข้อมูลใหม่
+const riskKeywordTTL = time.Minute
+
+var (
+	riskKeywordMu       sync.RWMutex
+	riskKeywordCache    []models.RiskKeyword
+	riskKeywordLoadedAt time.Time
+)
+
+// ลำดับความรุนแรง ใช้เลือกความรุนแรงสูงสุดเมื่อพบหลายคำในบันทึกเดียว
+var riskSeverityRank = map[string]int{"high": 1, "critical": 2}
+
+type RiskKeywordRequest struct {
+	Phrase   string `json:"phrase" validate:"required,max=255"`
+	Severity string `json:"severity" validate:"required,oneof=high critical"`
+	IsActive *bool  `json:"is_active"`
+}
+
+type RiskFlagQuery struct {
+	Status string `query:"status" validate:"omitempty,oneof=open acknowledged resolved"`
+	Page   int    `query:"page"`
+	Limit  int    `query:"limit"`
+}
+
+type RiskFlagUpdateRequest struct {
+	Status string `json:"status" validate:"required,oneof=open acknowledged resolved"`
+}
+
+// loadRiskKeywords คืนค่าคำเสี่ยงที่เปิดใช้งานอยู่
+func loadRiskKeywords() ([]models.RiskKeyword, error) {
+	riskKeywordMu.RLock()
+	if riskKeywordCache != nil && time.Since(riskKeywordLoadedAt) < riskKeywordTTL {
+		keywords := riskKeywordCache
+		riskKeywordMu.RUnlock()
+		return keywords, nil
+	}
+	riskKeywordMu.RUnlock()
+
+	keywords := []models.RiskKeyword{}
+	if err := database.DB.Where("is_active = ?", true).Find(&keywords).Error; err != nil {
+		return nil, err
+	}
+
+	riskKeywordMu.Lock()
+	riskKeywordCache = keywords
+	riskKeywordLoadedAt = time.Now()
+	riskKeywordMu.Unlock()
+
+	return keywords, nil
+}
+
+func invalidateRiskKeywords() {
+	riskKeywordMu.Lock()
+	riskKeywordCache = nil
+	riskKeywordMu.Unlock()
+}
+
+// privateRiskDisclosure คือนโยบายการเปิดเผยบันทึกส่วนตัวเมื่อพบความเสี่ยง ตั้งค่าผ่าน RISK_PRIVATE_DISCLOSURE
+//   - "none" (ค่าเริ่มต้น) อาจารย์ได้รับแจ้งว่ามีความเสี่ยงแต่ไม่ได้รับลิงก์หรือคำที่พบในบันทึกส่วนตัว
+//   - "critical" เปิดเผยบันทึกส่วนตัวให้อาจารย์เฉพาะเมื่อพบคำที่มีความรุนแรงระดับ critical
+func privateRiskDisclosure() string {
+	if os.Getenv("RISK_PRIVATE_DISCLOSURE") == "critical" {
+		return "critical"
+	}
+	return "none"
+}
+
+// canDiscloseRisk ตรวจว่านโยบายอนุญาตให้อาจารย์เห็นบันทึกและคำที่พบหรือไม่
+func canDiscloseRisk(diary models.Diary, severity string) bool {
+	if diary.IsShared != "private" {
+		return true
+	}
+	return privateRiskDisclosure() == "critical" && severity == "critical"
+}
+
+// scanDiaryRisk ค้นหาคำเสี่ยงในบันทึก สร้าง flag สำหรับคำที่พบใหม่ และแจ้งเตือนด่วนไปยังอาจารย์ที่ปรึกษา
+func scanDiaryRisk(diary models.Diary) error {
+	keywords, err := loadRiskKeywords()
+	if err != nil {
+		return err
+	}
+
+	text := risk.Normalize(htmlToText(diary.ContentHTML))
+	var newFlags []models.DiaryRiskFlag
+	for _, keyword := range keywords {
+		if !risk.Contains(text, keyword.Normalized) {
+			continue
+		}
+
+		var existing int64
+		if err := database.DB.Model(&models.DiaryRiskFlag{}).
+			Where("diary_id = ? AND keyword_id = ?", diary.ID, keyword.ID).
+			Count(&existing).Error; err != nil {
+			return err
+		}
+		if existing > 0 {
+			continue
+		}
+
+		flag := models.DiaryRiskFlag{
+			DiaryID:       diary.ID,
+			KeywordID:     keyword.ID,
+			StudentID:     diary.StudentID,
+			MatchedPhrase: keyword.Phrase,
+			Severity:      keyword.Severity,
+			Status:        "open",
+		}
+		if err := database.DB.Create(&flag).Error; err != nil {
+			return err
+		}
+		newFlags = append(newFlags, flag)
+	}
+
+	if len(newFlags) == 0 {
+		return nil
+	}
+	return notifyRiskFlags(diary, newFlags)
+}
+
+func notifyRiskFlags(diary models.Diary, flags []models.DiaryRiskFlag) error {
+	severity := "high"
+	phrases := make([]string, 0, len(flags))
+	for _, flag := range flags {
+		if riskSeverityRank[flag.Severity] > riskSeverityRank[severity] {
+			severity = flag.Severity
+		}
+		phrases = append(phrases, flag.MatchedPhrase)
+	}
+
+	var student models.User
+	if err := database.DB.First(&student, diary.StudentID).Error; err != nil {
+		return err
+	}
+
+	var links []models.StudentAdvisor
+	if err := database.DB.Where("student_id = ?", diary.StudentID).Find(&links).Error; err != nil {
+		return err
+	}
+
+	data := map[string]interface{}{
+		"student_id": diary.StudentID,
+		"severity":   severity,
+	}
+	notif := models.Notification{
+		Type:     "risk_alert",
+		Priority: "urgent",
+		Title:    "ด่วน: พบข้อความที่อาจบ่งชี้ความเสี่ยง",
+	}
+
+	if canDiscloseRisk(diary, severity) {
+		data["phrases"] = phrases
+		notif.DiaryID = &diary.ID
+		notif.Message = fmt.Sprintf("บันทึกของ %s มีข้อความที่อาจบ่งชี้ความเสี่ยง กรุณาติดต่อนิสิตโดยเร็ว", userDisplayName(student))
+	} else {
+		// บันทึกส่วนตัว: แจ้งเพียงว่ามีความเสี่ยง ไม่เปิดเผยบันทึกหรือคำที่พบ
+		notif.Message = fmt.Sprintf("ระบบพบข้อความที่อาจบ่งชี้ความเสี่ยงของ %s กรุณาติดต่อนิสิตโดยเร็ว", userDisplayName(student))
+	}
+	dataJSON, _ := json.Marshal(data)
+	notif.Data = dataJSON
+
+	for _, link := range links {
+		n := notif
+		n.UserID = link.AdvisorID
+		if err := notifyUser(&n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// onDiaryContentSaved เริ่มงานเบื้องหลังที่ต้องทำทุกครั้งที่เนื้อหาบันทึกเปลี่ยน
+func onDiaryContentSaved(diary models.Diary) {
+	analyzeDiarySentimentAsync(diary)
+	recordMentionsAsync(diary, nil, diary.StudentID, htmlToText(diary.ContentHTML))
+
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("Recovered from panic in risk scan for diary %d: %v", diary.ID, r)
+			}
+		}()
+		if err := scanDiaryRisk(diary); err != nil {
+			log.Printf("Risk scan failed for diary %d: %v", diary.ID, err)
+		}
+	}()
+}
+
+func GetRiskKeywords(c *fiber.Ctx) error {
+	keywords := []models.RiskKeyword{}
+	if err := database.DB.Order("phrase ASC").Find(&keywords).Error; err != nil {
+		return errInternal(err, "Failed to query risk keywords")
+	}
+
+	return respond(c, fiber.StatusOK, "Risk keywords retrieved successfully", keywords)
+}
+
+func CreateRiskKeyword(c *fiber.Ctx) error {
+	var input RiskKeywordRequest
+	if err := bindBody(c, &input); err != nil {
+		return err
+	}
+
+	normalized := risk.Normalize(input.Phrase)
+	if normalized == "" {
+		return errBadRequest("Phrase has no searchable characters")
+	}
+
+	var existing int64
+	if err := database.DB.Model(&models.RiskKeyword{}).Where("normalized = ?", normalized).Count(&existing).Error; err != nil {
+		return errInternal(err, "Failed to check risk keyword")
+	}
+	if existing > 0 {
+		return errConflict("Risk keyword already exists").WithTH("มีคำนี้อยู่แล้ว")
+	}
+
+	keyword := models.RiskKeyword{
+		Phrase:     input.Phrase,
+		Normalized: normalized,
+		Severity:   input.Severity,
+		IsActive:   true,
+	}
+	if err := database.DB.Create(&keyword).Error; err != nil {
+		return errInternal(err, "Failed to create risk keyword")
+	}
+	// Create ข้ามค่า false ของคอลัมน์ที่มีค่าเริ่มต้นเป็น true จึงต้องอัปเดตซ้ำ
+	if input.IsActive != nil && !*input.IsActive {
+		if err := database.DB.Model(&keyword).Update("is_active", false).Error; err != nil {
+			return errInternal(err, "Failed to update risk keyword")
+		}
+	}
+
+	invalidateRiskKeywords()
+	return respond(c, fiber.StatusCreated, "Risk keyword created successfully", keyword)
+}
+
+func UpdateRiskKeyword(c *fiber.Ctx) error {
+	id := c.Params("id")
+	var keyword models.RiskKeyword
+	if err := database.DB.First(&keyword, id).Error; err != nil {
+		return errLookup(err, "Risk keyword not found")
+	}
+
+	var input RiskKeywordRequest
+	if err := bindBody(c, &input); err != nil {
+		return err
+	}
+
+	normalized := risk.Normalize(input.Phrase)
+	if normalized == "" {
+		return errBadRequest("Phrase has no searchable characters")
+	}
+
+	var existing int64
+	if err := database.DB.Model(&models.RiskKeyword{}).
+		Where("normalized = ? AND id <> ?", normalized, keyword.ID).
+		Count(&existing).Error; err != nil {
+		return errInternal(err, "Failed to check risk keyword")
+	}
+	if existing > 0 {
+		return errConflict("Risk keyword already exists").WithTH("มีคำนี้อยู่แล้ว")
+	}
+
+	updates := map[string]interface{}{
+		"phrase":     input.Phrase,
+		"normalized": normalized,
+		"severity":   input.Severity,
+	}
+	if input.IsActive != nil {
+		updates["is_active"] = *input.IsActive
+	}
+	if err := database.DB.Model(&keyword).Updates(updates).Error; err != nil {
+		return errInternal(err, "Failed to update risk keyword")
+	}
+
+	invalidateRiskKeywords()
+	return respond(c, fiber.StatusOK, "Risk keyword updated successfully", keyword)
+}
+
+// DeleteRiskKeyword ลบคำเสี่ยงที่ยังไม่เคยถูกพบ คำที่มี flag อ้างถึงจะถูกปิดใช้งานแทน เพื่อเก็บประวัติการตรวจพบไว้
+func DeleteRiskKeyword(c *fiber.Ctx) error {
+	id := c.Params("id")
+	var keyword models.RiskKeyword
+	if err := database.DB.First(&keyword, id).Error; err != nil {
+		return errLookup(err, "Risk keyword not found")
+	}
+
+	var flags int64
+	if err := database.DB.Model(&models.DiaryRiskFlag{}).Where("keyword_id = ?", keyword.ID).Count(&flags).Error; err != nil {
+		return errInternal(err, "Failed to count risk flags")
+	}
+	if flags > 0 {
+		if err := database.DB.Model(&keyword).Update("is_active", false).Error; err != nil {
+			return errInternal(err, "Failed to deactivate risk keyword")
+		}
+		invalidateRiskKeywords()
+		return respond(c, fiber.StatusOK, "Risk keyword has flags and was deactivated instead of deleted", keyword)
+	}
+
+	if err := database.DB.Delete(&keyword).Error; err != nil {
+		return errInternal(err, "Failed to delete risk keyword")
+	}
+
+	invalidateRiskKeywords()
+	return respond(c, fiber.StatusOK, "Risk keyword deleted successfully", nil)
+}
+
+// GetRiskFlags คืนค่ารายการ flag สำหรับผู้ดูแลระบบ โดยไม่รวมเนื้อหาของบันทึก
+func GetRiskFlags(c *fiber.Ctx) error {
+	var query RiskFlagQuery
+	if err := bindQuery(c, &query); err != nil {
+		return err
+	}
+
+	page, limit := pageParams(query.Page, query.Limit)
+
+	dbQuery := database.DB.Model(&models.DiaryRiskFlag{})
+	if query.Status != "" {
+		dbQuery = dbQuery.Where("status = ?", query.Status)
+	}
+
+	var total int64
+	if err := dbQuery.Count(&total).Error; err != nil {
+		return errInternal(err, "Failed to count risk flags")
+	}
+
+	flags := []models.DiaryRiskFlag{}
+	if err := dbQuery.
+		Preload("Student").
+		Order("created_at DESC").
+		Offset((page - 1) * limit).
+		Limit(limit).
+		Find(&flags).Error; err != nil {
+		return errInternal(err, "Failed to query risk flags")
+	}
+
+	return respondMeta(c, fiber.StatusOK, "Risk flags retrieved successfully", flags, fiber.Map{
+		"count": len(flags),
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+}
+
+func UpdateRiskFlag(c *fiber.Ctx) error {
+	admin := currentUser(c)
+	if admin == nil {
+		return errUnauthorized("Unauthorized")
+	}
+
+	id := c.Params("id")
+	var flag models.DiaryRiskFlag
+	if err := database.DB.First(&flag, id).Error; err != nil {
+		return errLookup(err, "Risk flag not found")
+	}
+
+	var input RiskFlagUpdateRequest
+	if err := bindBody(c, &input); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if err := database.DB.Model(&flag).Updates(map[string]interface{}{
+		"status":      input.Status,
+		"reviewed_by": admin.ID,
+		"reviewed_at": now,
+	}).Error; err != nil {
+		return errInternal(err, "Failed to update risk flag")
+	}
+
+	return respond(c, fiber.StatusOK, "Risk flag updated successfully", flag)
+}
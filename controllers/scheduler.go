@@ -0,0 +1,114 @@
+package controllers
+
+import (
+	"context"
+	"database/sql"
+	"gofiber-auth/database"
+	"log"
+	"os"
+	"strconv"
+	"time"
+)
+
+// StartSchedulers เริ่มงานเบื้องหลังที่ทำงานเป็นรอบ ต้องเรียกหลังเชื่อมต่อฐานข้อมูลแล้ว
+func StartSchedulers() {
+	every(envDuration("MOOD_ALERT_INTERVAL", time.Hour), "mood alert sweep", sweepMoodAlerts)
+	every(envDuration("INACTIVITY_CHECK_INTERVAL", 6*time.Hour), "inactivity reminders", sendInactivityReminders)
+	every(envDuration("DIGEST_CHECK_INTERVAL", 15*time.Minute), "weekly digests", sendWeeklyDigests)
+}
+
+// every เรียก job ทุก interval ใน goroutine แยก ข้อผิดพลาดและ panic จะถูก log โดยไม่หยุดรอบถัดไป
+func every(interval time.Duration, name string, job func() error) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			runJob(name, job)
+		}
+	}()
+}
+
+// runJob รัน job ภายใต้ lock ของ MySQL (GET_LOCK) เพื่อให้เมื่อรันหลาย instance มีเพียงตัวเดียวที่ทำงานในแต่ละรอบ
+// instance ที่ได้ lock ไม่สำเร็จจะข้ามรอบนั้นไป
+func runJob(name string, job func() error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Recovered from panic in %s: %v", name, r)
+		}
+	}()
+
+	release, acquired, err := acquireJobLock(name)
+	if err != nil {
+		log.Printf("%s: failed to acquire lock: %v", name, err)
+		return
+	}
+	if !acquired {
+		log.Printf("%s skipped: another instance is running it", name)
+		return
+	}
+	defer release()
+
+	if err := job(); err != nil {
+		log.Printf("%s failed: %v", name, err)
+	}
+}
+
+// acquireJobLock ขอ named lock บน connection เฉพาะ เพราะ GET_LOCK ผูกกับ connection ที่ขอ
+// release จะปล่อย lock และคืน connection ให้ pool
+func acquireJobLock(name string) (release func(), acquired bool, err error) {
+	sqlDB, err := database.DB.DB()
+	if err != nil {
+		return nil, false, err
+	}
+	ctx := context.Background()
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+
+	lockName := "scheduler:" + name
+	var got sql.NullInt64
+	if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, 0)", lockName).Scan(&got); err != nil {
+		conn.Close()
+		return nil, false, err
+	}
+	if !got.Valid || got.Int64 != 1 {
+		conn.Close()
+		return nil, false, nil
+	}
+
+	return func() {
+		if _, err := conn.ExecContext(ctx, "SELECT RELEASE_LOCK(?)", lockName); err != nil {
+			log.Printf("%s: failed to release lock: %v", name, err)
+		}
+		conn.Close()
+	}, true, nil
+}
+
+// envDuration อ่านระยะเวลาจาก environment เช่น "30m" หรือคืนค่า fallback หากไม่ได้ตั้งค่าหรือรูปแบบผิด
+func envDuration(key string, fallback time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		log.Printf("Invalid %s=%q, using %s", key, value, fallback)
+		return fallback
+	}
+	return d
+}
+
+// envInt อ่านจำนวนเต็มบวกจาก environment หรือคืนค่า fallback หากไม่ได้ตั้งค่าหรือรูปแบบผิด
+func envInt(key string, fallback int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		log.Printf("Invalid %s=%q, using %d", key, value, fallback)
+		return fallback
+	}
+	return n
+}
@@ -3,37 +3,29 @@ package controllers
 import (
 	"gofiber-auth/database"
 	"gofiber-auth/models"
-	"strconv"
 
 	"github.com/gofiber/fiber/v2"
 )
 
 func GetStudentByAdvisor(c *fiber.Ctx) error {
-	advisorIDStr := c.Query("advisor_id")
-
-	if advisorIDStr == "" {
-		return c.Status(400).JSON(fiber.Map{
-			"error": "Student advisor are required",
-		})
+	var query AdvisorQuery
+	if err := bindQuery(c, &query); err != nil {
+		return err
 	}
 
 	var advisor []models.StudentAdvisor
 
 	result := database.DB.
 		Preload("Student").
-		Where("advisor_id = ?", advisorIDStr).
+		Where("advisor_id = ?", query.AdvisorID).
 		Find(&advisor)
 
 	if result.Error != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"error": result.Error.Error(),
-		})
+		return errInternal(result.Error, "Failed to retrieve students")
 	}
 
 	if len(advisor) == 0 {
-		return c.Status(404).JSON(fiber.Map{
-			"error": "No student entries found for this advisor_id",
-		})
+		return errNotFound("No student entries found for this advisor_id")
 	}
 
 	var resultList []fiber.Map
@@ -46,73 +38,47 @@ func GetStudentByAdvisor(c *fiber.Ctx) error {
 		})
 	}
 
-	return c.JSON(fiber.Map{
-		"message": "Advisor retrieved successfully",
-		"data":    resultList,
-	})
+	return respond(c, fiber.StatusOK, "Advisor retrieved successfully", resultList)
 }
 
 func DeleteStudentAdvisor(c *fiber.Ctx) error {
-	idParam := c.Query("id")
-	if idParam == "" {
-		return c.Status(400).JSON(fiber.Map{
-			"error": "Student advisor ID is required",
-		})
-	}
-
-	id, err := strconv.ParseUint(idParam, 10, 64)
-	if err != nil {
-		return c.Status(400).JSON(fiber.Map{
-			"error": "Invalid ID format",
-		})
+	var query IDQuery
+	if err := bindQuery(c, &query); err != nil {
+		return err
 	}
 
-	result := database.DB.Delete(&models.StudentAdvisor{}, id)
+	result := database.DB.Delete(&models.StudentAdvisor{}, query.ID)
 	if result.Error != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"error": result.Error.Error(),
-		})
+		return errInternal(result.Error, "Failed to delete student advisor")
 	}
 
 	if result.RowsAffected == 0 {
-		return c.Status(404).JSON(fiber.Map{
-			"error": "Student advisor not found",
-		})
+		return errNotFound("Student advisor not found")
 	}
 
-	return c.JSON(fiber.Map{
-		"ok":      true,
-		"message": "Advisor deleted successfully",
-	})
+	return respond(c, fiber.StatusOK, "Advisor deleted successfully", nil)
 }
 
 func CreateStudentAdvisor(c *fiber.Ctx) error {
 	var body struct {
-		StudentID uint `json:"student_id"`
-		AdvisorID uint `json:"advisor_id"`
+		StudentID uint `json:"student_id" validate:"required"`
+		AdvisorID uint `json:"advisor_id" validate:"required"`
 	}
-
-	if err := c.BodyParser(&body); err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid input"})
+	if err := bindBody(c, &body); err != nil {
+		return err
 	}
 
 	// เช็คว่าคำขอซ้ำอยู่แล้วหรือไม่
 	var existing models.AdvisorNotification
 	err := database.DB.Where("student_id = ? AND advisor_id = ?", body.StudentID, body.AdvisorID).First(&existing).Error
 	if err == nil {
-		return c.Status(400).JSON(fiber.Map{
-			"ok":      false,
-			"message": "คุณได้ส่งคำขออาจารย์ท่านนี้แล้ว",
-		})
+		return errConflict("You have already sent a request to this advisor").WithTH("คุณได้ส่งคำขออาจารย์ท่านนี้แล้ว")
 	}
 
 	var studentAdvisor models.StudentAdvisor
 	err = database.DB.Where("student_id = ? AND advisor_id = ?", body.StudentID, body.AdvisorID).First(&studentAdvisor).Error
 	if err == nil {
-		return c.Status(400).JSON(fiber.Map{
-			"ok":      false,
-			"message": "คุณเป็นนิสิตในการดูแลอาจารย์ท่านนี้แล้ว",
-		})
+		return errConflict("You are already supervised by this advisor").WithTH("คุณเป็นนิสิตในการดูแลอาจารย์ท่านนี้แล้ว")
 	}
 
 	// สร้างคำขอในตาราง AdvisorNotification
@@ -123,12 +89,10 @@ func CreateStudentAdvisor(c *fiber.Ctx) error {
 	}
 
 	if err := database.DB.Create(&notification).Error; err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		return errInternal(err, "Failed to create advisor request")
 	}
 
-	return c.JSON(fiber.Map{
-		"ok": true,
-	})
+	return respond(c, fiber.StatusOK, "Advisor request sent", nil)
 }
 
 func ApproveAdvisorRequest(c *fiber.Ctx) error {
@@ -136,7 +100,7 @@ func ApproveAdvisorRequest(c *fiber.Ctx) error {
 
 	var notif models.AdvisorNotification
 	if err := database.DB.First(&notif, id).Error; err != nil {
-		return c.Status(404).JSON(fiber.Map{"error": "Request not found"})
+		return errLookup(err, "Request not found")
 	}
 
 	// เพิ่ม StudentAdvisor
@@ -145,14 +109,14 @@ func ApproveAdvisorRequest(c *fiber.Ctx) error {
 		AdvisorID: notif.AdvisorID,
 	}
 	if err := database.DB.Create(&studentAdvisor).Error; err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		return errInternal(err, "Failed to create student advisor")
 	}
 
 	if err := database.DB.Delete(&notif).Error; err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		return errInternal(err, "Failed to delete advisor request")
 	}
 
-	return c.JSON(fiber.Map{"ok": true, "message": "อนุมัติคำขอเรียบร้อย"})
+	return respond(c, fiber.StatusOK, "อนุมัติคำขอเรียบร้อย", nil)
 }
 
 func UnApproveAdvisorRequest(c *fiber.Ctx) error {
@@ -160,31 +124,30 @@ func UnApproveAdvisorRequest(c *fiber.Ctx) error {
 	var notif models.AdvisorNotification
 
 	if err := database.DB.First(&notif, id).Error; err != nil {
-		return c.Status(404).JSON(fiber.Map{"error": "Request not found"})
+		return errLookup(err, "Request not found")
 	}
 
 	if err := database.DB.Delete(&notif).Error; err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
-
+		return errInternal(err, "Failed to delete advisor request")
 	}
 
-	return c.JSON(fiber.Map{"ok": true})
+	return respond(c, fiber.StatusOK, "Advisor request rejected", nil)
 }
 
 func GetAdvisorRequests(c *fiber.Ctx) error {
-	advisorID := c.Query("advisor_id")
-	if advisorID == "" {
-		return c.Status(400).JSON(fiber.Map{"error": "advisor_id required"})
+	var query AdvisorQuery
+	if err := bindQuery(c, &query); err != nil {
+		return err
 	}
 
 	var requests []models.AdvisorNotification
 	if err := database.DB.
 		Preload("Student").
-		Where("advisor_id = ? AND is_read = ?", advisorID, false).
+		Where("advisor_id = ? AND is_read = ?", query.AdvisorID, false).
 		Order("created_at desc").
 		Find(&requests).Error; err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		return errInternal(err, "Failed to retrieve advisor requests")
 	}
 
-	return c.JSON(fiber.Map{"data": requests})
+	return respond(c, fiber.StatusOK, "Advisor requests retrieved successfully", requests)
 }
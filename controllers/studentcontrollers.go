@@ -24,88 +24,67 @@ func GetAllStudents(c *fiber.Ctx) error {
 	var students []models.User
 	result := database.DB.Where("role = ?", "student").Find(&students)
 	if result.Error != nil {
-		return c.Status(fiber.StatusInternalServerError).SendString("Failed to query students")
+		return errInternal(result.Error, "Failed to query students")
 	}
 	if result.RowsAffected == 0 {
-		return c.Status(fiber.StatusNotFound).SendString("No students found")
+		return errNotFound("No students found")
 	}
 
-	return c.JSON(students)
+	return respond(c, fiber.StatusOK, "Students retrieved successfully", students)
 }
 
 func GetStudentById(c *fiber.Ctx) error {
-	id := c.Query("id")
-	var student models.User
+	var query IDQuery
+	if err := bindQuery(c, &query); err != nil {
+		return err
+	}
 
-	result := database.DB.Where("id = ?", id).First(&student)
-	if result.Error != nil {
-		c.Status(404)
-		return c.SendString(`student not found`)
+	var student models.User
+	if err := database.DB.Where("id = ?", query.ID).First(&student).Error; err != nil {
+		return errLookup(err, "student not found")
 	}
 
-	return c.JSON(student)
+	return respond(c, fiber.StatusOK, "Student retrieved successfully", student)
 }
 
 func CreateStudent(c *fiber.Ctx) error {
-	type Input struct {
-		Email     string `json:"email"`
-		AdvisorID uint   `json:"advisor_id"`
-	}
-	var input Input
-	if err := c.BodyParser(&input); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"message": "Invalid JSON body",
-		})
+	var input struct {
+		Email     string `json:"email" validate:"required,email"`
+		AdvisorID uint   `json:"advisor_id" validate:"required"`
 	}
-	if input.Email == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"message": "Email is required",
-		})
+	if err := bindBody(c, &input); err != nil {
+		return err
 	}
 
 	var advisor models.User
 	if err := database.DB.First(&advisor, input.AdvisorID).Error; err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"message": "Advisor not found",
-		})
+		return errLookup(err, "Advisor not found")
 	}
 	if advisor.Role != "advisor" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"message": "Provided user is not an advisor",
-		})
+		return errBadRequest("Provided user is not an advisor")
 	}
 
 	// ตรวจสอบ email นี้มีอยู่แล้วไหม
 	var student models.User
 	if err := database.DB.Where("email = ?", input.Email).First(&student).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-				"message": "ไม่พบนิสิตที่ใช้อีเมลนี้",
-			})
+			return errNotFound("No student uses this email").WithTH("ไม่พบนิสิตที่ใช้อีเมลนี้")
 		}
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"message": "Database error when checking for user account",
-		})
+		return errInternal(err, "Database error when checking for user account")
 	}
 
 	// ตรวจสอบว่า student มี role เป็น student"หรือไม่
 	if student.Role != "student" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"message": "ผู้ใช้บัญชีนี้ไม่ใช่นิสิต",
-		})
+		return errBadRequest("This account is not a student").WithTH("ผู้ใช้บัญชีนี้ไม่ใช่นิสิต")
 	}
 
 	// ตรวจสอบว่ามีความสัมพันธ์อยู่ไหม
 	var existingRelation models.StudentAdvisor
 	if err := database.DB.Where("advisor_id = ? AND student_id = ?", advisor.ID, student.ID).
 		First(&existingRelation).Error; err == nil {
-		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
-			"message": "คุณได้เพิ่มนิสิตคนนี้แล้ว",
-		})
+		return errConflict("You have already added this student").WithTH("คุณได้เพิ่มนิสิตคนนี้แล้ว")
 	} else if err != gorm.ErrRecordNotFound {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"message": "Database error when checking for existing relationship",
-		})
+		return errInternal(err, "Database error when checking for existing relationship")
 	}
 
 	// สร้างความสัมพันธ์ student กับ advisor
@@ -114,13 +93,10 @@ func CreateStudent(c *fiber.Ctx) error {
 		StudentID: student.ID,
 	}
 	if err := database.DB.Create(&studentAdvisor).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"message": "Failed to create student-advisor relation",
-		})
+		return errInternal(err, "Failed to create student-advisor relation")
 	}
 
-	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
-		"ok":      true,
+	return respond(c, fiber.StatusCreated, "Student added successfully", fiber.Map{
 		"user_id": student.ID,
 	})
 }
@@ -0,0 +1,200 @@
+package controllers
+
+import (
+	"gofiber-auth/database"
+	"gofiber-auth/models"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// statsRecentWeeks คือจำนวนสัปดาห์ล่าสุดที่แสดงจำนวนบันทึกรายสัปดาห์
+const statsRecentWeeks = 8
+
+type StudentStatsQuery struct {
+	StudentID uint `query:"student_id" validate:"required"`
+}
+
+type WeeklyEntryCount struct {
+	WeekStart string `json:"week_start"`
+	Entries   int64  `json:"entries"`
+}
+
+type StudentStats struct {
+	TotalEntries          int64              `json:"total_entries"`
+	CurrentStreak         int                `json:"current_streak"`
+	LongestStreak         int                `json:"longest_streak"`
+	LastEntryDate         *string            `json:"last_entry_date"`
+	AverageEntriesPerWeek float64            `json:"average_entries_per_week"`
+	RecentWeeks           []WeeklyEntryCount `json:"recent_weeks"`
+	AverageEntryLength    float64            `json:"average_entry_length"`
+	MostCommonMood        *models.MoodScale  `json:"most_common_mood"`
+	MoodCounts            map[string]int64   `json:"mood_counts"`
+	CommentsReceived      int64              `json:"comments_received"`
+	CommentsLast30Days    int64              `json:"comments_last_30_days"`
+}
+
+// journalingStreaks คำนวณ streak ปัจจุบันและยาวที่สุดจากวันที่เขียนที่เรียงจากเก่าไปใหม่และไม่ซ้ำกัน
+// streak ปัจจุบันยังนับต่อหากวันนี้ยังไม่ได้เขียนแต่เมื่อวานเขียนแล้ว
+func journalingStreaks(dates []time.Time, today time.Time) (current, longest int) {
+	run := 0
+	for i, d := range dates {
+		if i > 0 && d.Sub(dates[i-1]) == 24*time.Hour {
+			run++
+		} else {
+			run = 1
+		}
+		if run > longest {
+			longest = run
+		}
+	}
+
+	if len(dates) > 0 {
+		last := dates[len(dates)-1]
+		if gap := today.Sub(last); gap == 0 || gap == 24*time.Hour {
+			current = run
+		}
+	}
+	return current, longest
+}
+
+func GetStudentStats(c *fiber.Ctx) error {
+	user := currentUser(c)
+	if user == nil {
+		return errUnauthorized("Unauthorized")
+	}
+
+	var query StudentStatsQuery
+	if err := bindQuery(c, &query); err != nil {
+		return err
+	}
+
+	allowed, err := canViewStudent(user, query.StudentID)
+	if err != nil {
+		return errInternal(err, "Failed to check student access")
+	}
+	if !allowed {
+		return errForbidden("You cannot view this student's data")
+	}
+
+	var student models.User
+	if err := database.DB.Where("id = ? AND role = ?", query.StudentID, "student").First(&student).Error; err != nil {
+		return errLookup(err, "Student not found")
+	}
+
+	loc, _ := time.LoadLocation("Asia/Bangkok")
+	now := time.Now().In(loc)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	stats := StudentStats{
+		RecentWeeks: []WeeklyEntryCount{},
+		MoodCounts:  map[string]int64{},
+	}
+
+	// Session ทำให้ใช้ query ฐานเดียวกันซ้ำได้โดยเงื่อนไขของแต่ละคำสั่งไม่สะสมต่อกัน
+	diaries := database.DB.Model(&models.Diary{}).Where("student_id = ?", student.ID).Session(&gorm.Session{})
+
+	if err := diaries.Count(&stats.TotalEntries).Error; err != nil {
+		return errInternal(err, "Failed to count diaries")
+	}
+
+	var dateStrings []string
+	if err := diaries.
+		Select("DISTINCT DATE_FORMAT(diary_date, '%Y-%m-%d') AS day").
+		Order("day ASC").
+		Scan(&dateStrings).Error; err != nil {
+		return errInternal(err, "Failed to query diary dates")
+	}
+
+	dates := make([]time.Time, 0, len(dateStrings))
+	for _, s := range dateStrings {
+		if d, err := time.Parse(dateLayout, s); err == nil {
+			dates = append(dates, d)
+		}
+	}
+	stats.CurrentStreak, stats.LongestStreak = journalingStreaks(dates, today)
+
+	if len(dates) > 0 {
+		last := dates[len(dates)-1].Format(dateLayout)
+		stats.LastEntryDate = &last
+
+		weeks := today.Sub(dates[0]).Hours()/(24*7) + 1
+		stats.AverageEntriesPerWeek = float64(stats.TotalEntries) / weeks
+	}
+
+	firstWeek := periodStart(today, "week").AddDate(0, 0, -7*(statsRecentWeeks-1))
+	var weekRows []struct {
+		Period  string
+		Entries int64
+	}
+	if err := diaries.
+		Where("diary_date >= ?", firstWeek.Format(dateLayout)).
+		Select(periodExpr("week") + " AS period, COUNT(*) AS entries").
+		Group(periodExpr("week")).
+		Scan(&weekRows).Error; err != nil {
+		return errInternal(err, "Failed to query weekly entries")
+	}
+	byWeek := make(map[string]int64)
+	for _, row := range weekRows {
+		byWeek[row.Period] = row.Entries
+	}
+	for w := firstWeek; !w.After(today); w = w.AddDate(0, 0, 7) {
+		key := w.Format(dateLayout)
+		stats.RecentWeeks = append(stats.RecentWeeks, WeeklyEntryCount{WeekStart: key, Entries: byWeek[key]})
+	}
+
+	var contents []string
+	if err := diaries.Pluck("content_html", &contents).Error; err != nil {
+		return errInternal(err, "Failed to query diary content")
+	}
+	if len(contents) > 0 {
+		total := 0
+		for _, content := range contents {
+			total += utf8.RuneCountInString(htmlToText(content))
+		}
+		stats.AverageEntryLength = float64(total) / float64(len(contents))
+	}
+
+	var moodRows []struct {
+		Status string
+		Total  int64
+	}
+	if err := diaries.
+		Select("status, COUNT(*) AS total").
+		Group("status").
+		Order("total DESC").
+		Scan(&moodRows).Error; err != nil {
+		return errInternal(err, "Failed to query moods")
+	}
+	for _, row := range moodRows {
+		stats.MoodCounts[row.Status] = row.Total
+	}
+	if len(moodRows) > 0 {
+		if scale, err := loadMoodScale(); err == nil {
+			for i := range scale {
+				if scale[i].Key == moodRows[0].Status {
+					stats.MostCommonMood = &scale[i]
+					break
+				}
+			}
+		}
+	}
+
+	// นับเฉพาะความคิดเห็นจากผู้อื่น ไม่รวมความคิดเห็นที่นิสิตตอบในบันทึกของตัวเอง
+	comments := database.DB.Model(&models.Comment{}).
+		Joins("JOIN diaries ON diaries.id = comments.diary_id").
+		Where("diaries.student_id = ? AND comments.author_id <> ?", student.ID, student.ID).
+		Session(&gorm.Session{})
+	if err := comments.Count(&stats.CommentsReceived).Error; err != nil {
+		return errInternal(err, "Failed to count comments")
+	}
+	if err := comments.
+		Where("comments.created_at >= ?", time.Now().AddDate(0, 0, -30)).
+		Count(&stats.CommentsLast30Days).Error; err != nil {
+		return errInternal(err, "Failed to count comments")
+	}
+
+	return respond(c, fiber.StatusOK, "Student statistics retrieved successfully", stats)
+}
@@ -15,77 +15,92 @@ import (
 func GetUser(c *fiber.Ctx) error {
 	id := c.Params("id")
 	var user models.User
-	result := database.DB.First(&user, id)
-	if result.Error != nil {
-		c.Status(404)
-		return c.SendString("User not found")
+	if err := database.DB.First(&user, id).Error; err != nil {
+		return errLookup(err, "User not found")
 	}
-	return c.JSON(user)
+	return respond(c, fiber.StatusOK, "User retrieved successfully", user)
 }
 
 func GetUserByEmail(c *fiber.Ctx) error {
-	email := c.Query("email")
-	var user models.User
-
-	result := database.DB.Where("email = ?", email).First(&user)
+	var query struct {
+		Email string `query:"email" validate:"required,email"`
+	}
+	if err := bindQuery(c, &query); err != nil {
+		return err
+	}
 
-	if result.Error != nil {
-		c.Status(404)
-		return c.SendString("User not found")
+	var user models.User
+	if err := database.DB.Where("email = ?", query.Email).First(&user).Error; err != nil {
+		return errLookup(err, "User not found")
 	}
 
-	return c.JSON(user)
+	return respond(c, fiber.StatusOK, "User retrieved successfully", user)
 }
 
 func GetAllUser(c *fiber.Ctx) error {
-	var user models.User
+	var users []models.User
 
-	result := database.DB.Find(&user)
-	if result.Error != nil {
-		c.Status(404)
-		return c.SendString("User not found")
+	if err := database.DB.Find(&users).Error; err != nil {
+		return errInternal(err, "Failed to query users")
 	}
 
-	return c.JSON(user)
+	return respond(c, fiber.StatusOK, "Users retrieved successfully", users)
+}
+
+type CreateUserRequest struct {
+	Name     *string `json:"Name" validate:"omitempty,max=255"`
+	Email    string  `json:"Email" validate:"required,email,max=255"`
+	Password *string `json:"Password" validate:"omitempty,max=72"`
+	Approved *bool   `json:"Approved"`
+	Image    *string `json:"Image"`
+	Role     string  `json:"Role" validate:"required,oneof=student advisor admin"`
 }
 
 func CreateUser(c *fiber.Ctx) error {
-	var user models.User
-	if err := c.BodyParser(&user); err != nil {
-		c.Status(400)
-		return c.SendString("Cannot parse JSON")
+	var input CreateUserRequest
+	if err := bindBody(c, &input); err != nil {
+		return err
 	}
 
-	database.DB.Create(&user)
-
-	return c.SendString("User created successfully")
-}
+	user := models.User{
+		Name:     input.Name,
+		Email:    input.Email,
+		Approved: input.Approved == nil || *input.Approved,
+		Image:    input.Image,
+		Role:     input.Role,
+	}
 
-func RegisterHandler(c *fiber.Ctx) error {
-	type RegisterInput struct {
-		Name     string `json:"name"`
-		Email    string `json:"email"`
-		Password string `json:"password"`
+	if input.Password != nil {
+		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(*input.Password), bcrypt.DefaultCost)
+		if err != nil {
+			return errInternal(err, "Could not hash password")
+		}
+		hashed := string(hashedPassword)
+		user.Password = &hashed
 	}
 
-	var input RegisterInput
-	if err := c.BodyParser(&input); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid input",
-		})
+	if err := database.DB.Create(&user).Error; err != nil {
+		return errInternal(err, "Failed to create user")
 	}
 
-	if input.Email == "" || input.Password == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Email and password are required",
-		})
+	return respond(c, fiber.StatusCreated, "User created successfully", user)
+}
+
+type RegisterRequest struct {
+	Name     string `json:"name" validate:"max=255"`
+	Email    string `json:"email" validate:"required,email,max=255"`
+	Password string `json:"password" validate:"required,max=72"`
+}
+
+func RegisterHandler(c *fiber.Ctx) error {
+	var input RegisterRequest
+	if err := bindBody(c, &input); err != nil {
+		return err
 	}
 
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Could not hash password",
-		})
+		return errInternal(err, "Could not hash password")
 	}
 
 	user := models.User{
@@ -96,74 +111,88 @@ func RegisterHandler(c *fiber.Ctx) error {
 	}
 
 	if err := database.DB.Create(&user).Error; err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Email already exists",
-		})
+		return errConflict("Email already exists")
 	}
 
 	if err := database.DB.Model(&user).Update("Approved", false).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Could not set Approved to false",
-		})
+		return errInternal(err, "Could not set Approved to false")
 	}
 
-	return c.JSON(fiber.Map{
-		"message": "User registered successfully",
-	})
+	return respond(c, fiber.StatusOK, "User registered successfully", nil)
 }
 
 func GetAllUserNotconfirmed(c *fiber.Ctx) error {
 	var users []models.User
 
-	result := database.DB.Where("approved = ?", 0).Find(&users)
-	if result.Error != nil {
-		c.Status(500)
-		return c.SendString("Database error")
+	if err := database.DB.Where("approved = ?", 0).Find(&users).Error; err != nil {
+		return errInternal(err, "Failed to query users")
 	}
 
 	if len(users) == 0 {
-		c.Status(404)
-		return c.SendString("No unconfirmed users found")
+		return errNotFound("No unconfirmed users found")
 	}
 
-	return c.JSON(users)
+	return respond(c, fiber.StatusOK, "Unconfirmed users retrieved successfully", users)
+}
+
+// userPatchFields คือฟิลด์ของ User ที่แก้ไขได้ผ่าน PATCH แยกตามบทบาทของผู้เรียก
+// "self" คือผู้ใช้ที่แก้ไขบัญชีของตัวเอง
+var userPatchFields = []patchField{
+	{Column: "approved", Aliases: []string{"Approved"}, Roles: []string{"admin"}, Decode: decodePatchBool},
+	{Column: "role", Aliases: []string{"Role"}, Roles: []string{"admin"}, Decode: decodePatchString, Rules: "oneof=student advisor admin"},
+	{Column: "name", Aliases: []string{"Name"}, Roles: []string{"admin", "self"}, Nullable: true, Decode: decodePatchString, Rules: "required,max=255"},
+	{Column: "image", Aliases: []string{"Image"}, Roles: []string{"self"}, Nullable: true, Decode: decodePatchString},
 }
 
 func PatchApproved(c *fiber.Ctx) error {
+	caller := currentUser(c)
+	if caller == nil {
+		return errUnauthorized("Unauthorized")
+	}
+
 	id := c.Params("id")
 	var user models.User
 
-	if result := database.DB.First(&user, id); result.Error != nil {
-		return c.Status(404).JSON(fiber.Map{
-			"error": "User not found",
-		})
+	if err := database.DB.First(&user, id).Error; err != nil {
+		return errLookup(err, "User not found")
+	}
+
+	role := caller.Role
+	if role != "admin" {
+		if caller.ID != user.ID {
+			return errForbidden("You are not allowed to modify this user")
+		}
+		role = "self"
 	}
 
-	var updateApproved map[string]interface{}
-	if err := c.BodyParser(&updateApproved); err != nil {
-		return c.Status(400).JSON(fiber.Map{
-			"error":   "Cannot parse JSON",
-			"details": err.Error(),
-		})
+	updateApproved, fieldErrs, err := applyMergePatch(c.Body(), userPatchFields, role)
+	if err != nil {
+		return errBadRequest("Cannot parse JSON").WithTH("รูปแบบข้อมูลไม่ถูกต้อง")
+	}
+	if len(fieldErrs) > 0 {
+		return errValidation(fieldErrs)
 	}
 
 	if err := database.DB.Model(&user).Updates(updateApproved).Error; err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"error":   "Failed to update approved",
-			"details": err.Error(),
-		})
+		return errInternal(err, "Failed to update approved")
 	}
 
-	return c.Status(200).JSON(fiber.Map{
-		"ok":      true,
-		"message": "Patched approved successfully",
-	})
+	return respond(c, fiber.StatusOK, "Patched approved successfully", nil)
+}
+
+// currentUser คืนค่าผู้ใช้ที่ AuthMiddleware ตรวจสอบแล้ว หรือ nil หากยังไม่ได้ยืนยันตัวตน
+func currentUser(c *fiber.Ctx) *models.User {
+	user, ok := c.Locals("user").(*models.User)
+	if !ok {
+		return nil
+	}
+	return user
 }
 
 func AuthMiddleware(c *fiber.Ctx) error {
 	authHeader := c.Get("Authorization")
 	if authHeader == "" {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Missing token"})
+		return errUnauthorized("Missing token")
 	}
 
 	tokenStr := strings.Replace(authHeader, "Bearer ", "", 1)
@@ -176,58 +205,184 @@ func AuthMiddleware(c *fiber.Ctx) error {
 	})
 
 	if err != nil || !token.Valid {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+		return errUnauthorized("Invalid token")
 	}
 
 	claims, ok := token.Claims.(jwt.MapClaims)
 	if !ok {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token claims"})
+		return errUnauthorized("Invalid token claims")
 	}
 
 	userIDFloat, ok := claims["user_id"].(float64)
 	if !ok {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid user_id in token"})
+		return errUnauthorized("Invalid user_id in token")
 	}
 	userID := uint(userIDFloat)
 
 	var user models.User
 	if err := database.DB.First(&user, userID).Error; err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "User not found"})
+		return errLookup(err, "User not found")
 	}
 
 	c.Locals("user", &user)
 	return c.Next()
 }
 
+// OptionalAuthMiddleware ระบุตัวผู้ใช้เมื่อมี Authorization header และปล่อยผ่านเมื่อไม่มี
+// ใช้กับ endpoint ที่เปิดสาธารณะแต่ทำงานเพิ่มเติมเมื่อรู้ว่าใครเรียก
+func OptionalAuthMiddleware(c *fiber.Ctx) error {
+	if c.Get("Authorization") == "" {
+		return c.Next()
+	}
+	return AuthMiddleware(c)
+}
+
+// RequireRole อนุญาตเฉพาะผู้ใช้ที่มีบทบาทตามที่กำหนด ต้องใช้หลัง AuthMiddleware
+func RequireRole(roles ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		user := currentUser(c)
+		if user == nil {
+			return errUnauthorized("Unauthorized")
+		}
+		for _, role := range roles {
+			if user.Role == role {
+				return c.Next()
+			}
+		}
+		return errForbidden("You do not have permission to access this resource")
+	}
+}
+
+// scopedAdvisorID คืนค่าอาจารย์ที่ผู้ใช้ดูข้อมูลได้ ค่าเริ่มต้นคือตนเอง มีเพียงผู้ดูแลระบบที่ระบุ advisor_id ของอาจารย์คนอื่นได้
+func scopedAdvisorID(user *models.User, requested uint) (uint, error) {
+	if requested == 0 || requested == user.ID {
+		return user.ID, nil
+	}
+	if user.Role != "admin" {
+		return 0, errForbidden("You can only access data of your own students")
+	}
+	return requested, nil
+}
+
+// canViewGroup อนุญาตให้อาจารย์ประจำกลุ่มและผู้ดูแลระบบดูข้อมูลของกลุ่ม
+func canViewGroup(user *models.User, group models.Group) bool {
+	return user.Role == "admin" || group.AdvisorID == user.ID
+}
+
+// canViewStudent อนุญาตให้นิสิตเอง อาจารย์ที่ปรึกษา อาจารย์ประจำกลุ่มของนิสิต และผู้ดูแลระบบดูข้อมูลของนิสิต
+func canViewStudent(user *models.User, studentID uint) (bool, error) {
+	if user.Role == "admin" || user.ID == studentID {
+		return true, nil
+	}
+	if user.Role != "advisor" {
+		return false, nil
+	}
+
+	var count int64
+	if err := database.DB.Model(&models.StudentAdvisor{}).
+		Where("advisor_id = ? AND student_id = ?", user.ID, studentID).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	if count > 0 {
+		return true, nil
+	}
+
+	if err := database.DB.Table("`groups`").
+		Joins("JOIN student_groups ON student_groups.group_id = `groups`.id").
+		Where("`groups`.advisor_id = ? AND student_groups.student_id = ?", user.ID, studentID).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// diaryAudience คืนค่าผู้ใช้ที่มองเห็นบันทึกได้ ซึ่งเป็นกลุ่มเดียวที่ถูกกล่าวถึงในบันทึกได้
+//   - private: เฉพาะนิสิตเจ้าของบันทึก
+//   - advisor: เพิ่มอาจารย์ที่ปรึกษาและอาจารย์ของกลุ่มที่นิสิตเป็นสมาชิก
+//   - everyone: เพิ่มสมาชิกคนอื่นในกลุ่มเดียวกัน
+func diaryAudience(diary models.Diary) ([]models.User, error) {
+	ids := []uint{diary.StudentID}
+
+	if diary.IsShared != "private" {
+		var advisorIDs []uint
+		if err := database.DB.Model(&models.StudentAdvisor{}).
+			Where("student_id = ?", diary.StudentID).
+			Pluck("advisor_id", &advisorIDs).Error; err != nil {
+			return nil, err
+		}
+		ids = append(ids, advisorIDs...)
+
+		var groupAdvisorIDs []uint
+		if err := database.DB.Table("`groups`").
+			Joins("JOIN student_groups ON student_groups.group_id = `groups`.id").
+			Where("student_groups.student_id = ?", diary.StudentID).
+			Pluck("`groups`.advisor_id", &groupAdvisorIDs).Error; err != nil {
+			return nil, err
+		}
+		ids = append(ids, groupAdvisorIDs...)
+	}
+
+	if diary.IsShared == "everyone" {
+		var memberIDs []uint
+		if err := database.DB.Model(&models.StudentGroup{}).
+			Where("group_id IN (?)", database.DB.Model(&models.StudentGroup{}).Select("group_id").Where("student_id = ?", diary.StudentID)).
+			Pluck("student_id", &memberIDs).Error; err != nil {
+			return nil, err
+		}
+		ids = append(ids, memberIDs...)
+	}
+
+	var users []models.User
+	if err := database.DB.Where("id IN ?", ids).Find(&users).Error; err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// canViewDiary ตรวจว่าผู้ใช้อยู่ในกลุ่มที่มองเห็นบันทึก ผู้ดูแลระบบมองเห็นทุกบันทึก
+func canViewDiary(user *models.User, diary models.Diary) (bool, error) {
+	if user.Role == "admin" || user.ID == diary.StudentID {
+		return true, nil
+	}
+	audience, err := diaryAudience(diary)
+	if err != nil {
+		return false, err
+	}
+	for _, member := range audience {
+		if member.ID == user.ID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
 func DeleteApprover(c *fiber.Ctx) error {
 	idParam := c.Params("id")
 	id, err := strconv.Atoi(idParam)
 	if err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid ID"})
+		return errBadRequest("Invalid ID")
 	}
 
 	var user models.User
-	if result := database.DB.First(&user, id); result.Error != nil {
-		return c.Status(404).JSON(fiber.Map{"error": "User not found"})
+	if err := database.DB.First(&user, id).Error; err != nil {
+		return errLookup(err, "User not found")
 	}
 
 	if err := database.DB.Delete(&user).Error; err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to delete user", "details": err.Error()})
+		return errInternal(err, "Failed to delete user")
 	}
 
-	return c.JSON(fiber.Map{
-		"ok":      true,
-		"message": "User deleted successfully",
-	})
+	return respond(c, fiber.StatusOK, "User deleted successfully", nil)
 }
 
 func GetProfileHandler(c *fiber.Ctx) error {
-	user, ok := c.Locals("user").(*models.User)
-	if !ok || user == nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "User not found"})
+	user := currentUser(c)
+	if user == nil {
+		return errNotFound("User not found")
 	}
 
-	return c.JSON(fiber.Map{
+	return respond(c, fiber.StatusOK, "Profile retrieved successfully", fiber.Map{
 		"id":       user.ID,
 		"name":     user.Name,
 		"email":    user.Email,
@@ -6,6 +6,7 @@ import (
 	"os"
 
 	"gofiber-auth/models"
+	"gofiber-auth/risk"
 
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
@@ -41,14 +42,90 @@ func autoMigrate() {
 		&models.StudentAdvisor{},
 		&models.Diary{},
 		&models.Comment{},
+		&models.CommentRevision{},
+		&models.CommentReport{},
 		&models.Attachment{},
 		&models.Notification{},
 		&models.Group{},
 		&models.StudentGroup{},
 		&models.AdvisorNotification{},
+		&models.MoodScale{},
+		&models.MoodAlertSetting{},
+		&models.MoodAlert{},
+		&models.RiskKeyword{},
+		&models.DiaryRiskFlag{},
+		&models.DigestSetting{},
+		&models.Mention{},
+		&models.Reaction{},
+		&models.DiaryRead{},
+		&models.AdvisorNote{},
 	)
 
 	if err != nil {
 		log.Fatalf(" Auto migration failed: %v", err)
 	}
+
+	seedMoodScale()
+	seedRiskKeywords()
+}
+
+// seedMoodScale ใส่ระดับอารมณ์เริ่มต้นเมื่อยังไม่มีการตั้งค่าใด ๆ
+func seedMoodScale() {
+	var count int64
+	if err := DB.Model(&models.MoodScale{}).Count(&count).Error; err != nil {
+		log.Fatalf("Failed to count mood scale: %v", err)
+	}
+	if count > 0 {
+		return
+	}
+
+	defaults := []models.MoodScale{
+		{Key: "veryHappy", LabelTH: "มีความสุขมาก", LabelEN: "Very happy", Score: 5, Color: "#22c55e", Emoji: "😄", SortOrder: 1},
+		{Key: "happy", LabelTH: "มีความสุข", LabelEN: "Happy", Score: 4, Color: "#84cc16", Emoji: "🙂", SortOrder: 2},
+		{Key: "neutral", LabelTH: "เฉย ๆ", LabelEN: "Neutral", Score: 3, Color: "#eab308", Emoji: "😐", SortOrder: 3, IsDefault: true},
+		{Key: "stressed", LabelTH: "เครียด", LabelEN: "Stressed", Score: 2, Color: "#f97316", Emoji: "😣", SortOrder: 4},
+		{Key: "burnedOut", LabelTH: "หมดไฟ", LabelEN: "Burned out", Score: 1, Color: "#ef4444", Emoji: "😫", SortOrder: 5},
+	}
+	if err := DB.Create(&defaults).Error; err != nil {
+		log.Fatalf("Failed to seed mood scale: %v", err)
+	}
+}
+
+// seedRiskKeywords ใส่คำเสี่ยงเริ่มต้นเมื่อยังไม่มีการตั้งค่าใด ๆ ผู้ดูแลระบบแก้ไขเพิ่มเติมได้ภายหลัง
+func seedRiskKeywords() {
+	var count int64
+	if err := DB.Model(&models.RiskKeyword{}).Count(&count).Error; err != nil {
+		log.Fatalf("Failed to count risk keywords: %v", err)
+	}
+	if count > 0 {
+		return
+	}
+
+	phrases := map[string]string{
+		"ฆ่าตัวตาย":          "critical",
+		"อยากตาย":            "critical",
+		"ไม่อยากมีชีวิตอยู่": "critical",
+		"จบชีวิต":            "critical",
+		"ทำร้ายตัวเอง":       "high",
+		"กรีดข้อมือ":         "high",
+		"suicide":            "critical",
+		"kill myself":        "critical",
+		"want to die":        "critical",
+		"end my life":        "critical",
+		"self harm":          "high",
+		"hurt myself":        "high",
+	}
+
+	keywords := make([]models.RiskKeyword, 0, len(phrases))
+	for phrase, severity := range phrases {
+		keywords = append(keywords, models.RiskKeyword{
+			Phrase:     phrase,
+			Normalized: risk.Normalize(phrase),
+			Severity:   severity,
+			IsActive:   true,
+		})
+	}
+	if err := DB.Create(&keywords).Error; err != nil {
+		log.Fatalf("Failed to seed risk keywords: %v", err)
+	}
 }
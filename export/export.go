@@ -0,0 +1,54 @@
+package export
+
+import (
+	"fmt"
+	"io"
+)
+
+// Writer เขียนตารางทีละแถวลงใน stream โดยไม่ต้องเก็บข้อมูลทั้งหมดไว้ในหน่วยความจำ
+// ค่าในแถวเป็น string, จำนวนเต็ม, ทศนิยม หรือ nil
+type Writer interface {
+	WriteRow(values []interface{}) error
+	Close() error
+}
+
+// Format คือรูปแบบไฟล์ที่รองรับ
+type Format string
+
+const (
+	CSV  Format = "csv"
+	XLSX Format = "xlsx"
+)
+
+// ContentType คืนค่า MIME type ของรูปแบบไฟล์
+func (f Format) ContentType() string {
+	if f == XLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// NewWriter สร้าง Writer ตามรูปแบบไฟล์ sheet ใช้เป็นชื่อแผ่นงานของ XLSX
+func NewWriter(format Format, w io.Writer, sheet string) (Writer, error) {
+	switch format {
+	case CSV:
+		return newCSVWriter(w)
+	case XLSX:
+		return newXLSXWriter(w, sheet)
+	}
+	return nil, fmt.Errorf("export: unsupported format %q", format)
+}
+
+func formatValue(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case []byte:
+		return string(val)
+	case float64:
+		return fmt.Sprintf("%.2f", val)
+	}
+	return fmt.Sprint(v)
+}
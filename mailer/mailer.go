@@ -0,0 +1,122 @@
+package mailer
+
+import (
+	"fmt"
+	"log"
+	"mime"
+	"net/smtp"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Message คืออีเมลหนึ่งฉบับ Body เป็น HTML เมื่อ HTML เป็น true มิฉะนั้นเป็นข้อความธรรมดา
+type Message struct {
+	To      []string
+	Subject string
+	Body    string
+	HTML    bool
+}
+
+// Mailer คือช่องทางส่งอีเมลที่เปลี่ยนได้ เช่น SMTP หรือ log สำหรับการพัฒนา
+type Mailer interface {
+	Send(msg Message) error
+}
+
+// SMTPMailer ส่งอีเมลผ่านเซิร์ฟเวอร์ SMTP
+type SMTPMailer struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+func (m *SMTPMailer) Send(msg Message) error {
+	if len(msg.To) == 0 {
+		return fmt.Errorf("mailer: no recipients")
+	}
+
+	var auth smtp.Auth
+	if m.Username != "" {
+		auth = smtp.PlainAuth("", m.Username, m.Password, m.Host)
+	}
+
+	contentType := "text/plain"
+	if msg.HTML {
+		contentType = "text/html"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", m.From)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(msg.To, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&b, "Content-Type: %s; charset=\"utf-8\"\r\n", contentType)
+	b.WriteString("\r\n")
+	b.WriteString(msg.Body)
+
+	return smtp.SendMail(m.Host+":"+m.Port, auth, m.From, msg.To, []byte(b.String()))
+}
+
+// LogMailer เขียนอีเมลลง log แทนการส่งจริง ใช้เมื่อไม่ได้ตั้งค่า SMTP
+type LogMailer struct{}
+
+func (LogMailer) Send(msg Message) error {
+	log.Printf("[mailer] to=%s subject=%q (%d bytes)", strings.Join(msg.To, ","), msg.Subject, len(msg.Body))
+	return nil
+}
+
+var (
+	mu      sync.RWMutex
+	current Mailer
+)
+
+// FromEnv สร้าง Mailer จาก SMTP_HOST, SMTP_PORT, SMTP_USERNAME, SMTP_PASSWORD และ SMTP_FROM
+// หากไม่ได้ตั้งค่า SMTP_HOST จะใช้ LogMailer
+func FromEnv() Mailer {
+	host := os.Getenv("SMTP_HOST")
+	if host == "" {
+		return LogMailer{}
+	}
+	port := os.Getenv("SMTP_PORT")
+	if port == "" {
+		port = "587"
+	}
+	return &SMTPMailer{
+		Host:     host,
+		Port:     port,
+		Username: os.Getenv("SMTP_USERNAME"),
+		Password: os.Getenv("SMTP_PASSWORD"),
+		From:     os.Getenv("SMTP_FROM"),
+	}
+}
+
+// SetDefault เปลี่ยน Mailer ที่ใช้โดย Send
+func SetDefault(m Mailer) {
+	mu.Lock()
+	current = m
+	mu.Unlock()
+}
+
+// Default คืนค่า Mailer ปัจจุบัน โดยสร้างจาก environment ในครั้งแรกที่เรียก
+func Default() Mailer {
+	mu.RLock()
+	m := current
+	mu.RUnlock()
+	if m != nil {
+		return m
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if current == nil {
+		current = FromEnv()
+	}
+	return current
+}
+
+// Send ส่งอีเมลผ่าน Mailer ปัจจุบัน
+func Send(msg Message) error {
+	return Default().Send(msg)
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"gofiber-auth/controllers"
 	"gofiber-auth/database"
 	"gofiber-auth/routers"
 	"log"
@@ -11,6 +12,7 @@ import (
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/logger"
 	"github.com/gofiber/fiber/v2/middleware/recover"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
 	"github.com/joho/godotenv"
 )
 
@@ -30,24 +32,24 @@ func main() {
 		IdleTimeout:       time.Minute * 5,
 		DisableKeepalive:  false,
 		StreamRequestBody: true,
+		ErrorHandler:      controllers.ErrorHandler,
 	})
 
 	app.Use(recover.New())
+	app.Use(requestid.New())
 	app.Use(logger.New(logger.Config{
-		Format: "${time} ${status} - ${method} ${path} - ${latency}\n",
+		Format: "${time} ${locals:requestid} ${status} - ${method} ${path} - ${latency}\n",
 	}))
 
 	app.Use(cors.New(cors.Config{
 		AllowOrigins:     os.Getenv("CORS_ALLOW_ORIGINS"),
 		AllowMethods:     "GET,POST,PUT,PATCH,DELETE,OPTIONS,HEAD",
-		AllowHeaders:     "Origin,Content-Type,Accept,Authorization,X-Requested-With,Cache-Control",
-		ExposeHeaders:    "Content-Type,Cache-Control",
+		AllowHeaders:     "Origin,Content-Type,Accept,Authorization,X-Requested-With,Cache-Control,If-Match",
+		ExposeHeaders:    "Content-Type,Content-Disposition,Cache-Control,ETag,X-Request-ID",
 		AllowCredentials: true,
 		MaxAge:           86400,
 	}))
 
-	app.Static("/api/files", "./upload/diary")
-
 	app.Get("/", func(c *fiber.Ctx) error {
 		return c.SendString("Go Fiber Server is running!")
 	})
@@ -70,6 +72,15 @@ func main() {
 	routers.NotificationRouters(app)
 	routers.GroupRouter(app)
 	routers.AdminRouter(app)
+	routers.MoodAlertRouter(app)
+	routers.DashboardRouter(app)
+	routers.ReportRouter(app)
+	routers.DigestRouter(app)
+	routers.MentionRouter(app)
+	routers.ReactionRouter(app)
+	routers.AdvisorNoteRouter(app)
+
+	controllers.StartSchedulers()
 
 	port := os.Getenv("PORT")
 	if port == "" {
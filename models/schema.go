@@ -10,7 +10,7 @@ type User struct {
 	ID        uint      `gorm:"primaryKey;autoIncrement"`
 	Name      *string   `gorm:"size:255"`
 	Email     string    `gorm:"size:255;unique;not null"`
-	Password  *string   `gorm:"size:255"`
+	Password  *string   `gorm:"size:255" json:"-"`
 	Approved  bool      `gorm:"not null;default:true"`
 	Image     *string   `gorm:"type:longtext"`
 	Role      string    `gorm:"type:varchar(20);not null"`
@@ -56,8 +56,16 @@ type Diary struct {
 	AllowComment bool           `gorm:"default:true"`
 	Status       string         `gorm:"default:neutral"`
 	DiaryDate    time.Time      `gorm:"type:date;not null"`
-	CreatedAt    time.Time      `gorm:"autoCreateTime"`
-	UpdatedAt    time.Time      `gorm:"autoUpdateTime"`
+	Version      uint           `gorm:"not null;default:1"`
+	// SentimentScore คือผลวิเคราะห์ความรู้สึกจากเนื้อหา (-1 ถึง 1) เป็น nil หากยังไม่วิเคราะห์หรือไม่พบคำที่มีความรู้สึก
+	// ไม่ส่งใน response ของบันทึก ใช้เฉพาะในสถิติอารมณ์สำหรับอาจารย์
+	SentimentScore *float64  `gorm:"index" json:"-"`
+	CreatedAt      time.Time `gorm:"autoCreateTime"`
+	UpdatedAt      time.Time `gorm:"autoUpdateTime"`
+	// Reactions คือจำนวน reaction แยกตาม emoji ไม่ได้เก็บในตาราง เติมเมื่อส่ง response
+	Reactions map[string]int64 `gorm:"-" json:"Reactions,omitempty"`
+	// SeenBy คืออาจารย์ที่เปิดอ่านบันทึกแล้ว เติมเมื่อส่ง response
+	SeenBy []DiaryRead `gorm:"-" json:"SeenBy,omitempty"`
 
 	Student     User         `gorm:"foreignKey:StudentID;constraint:OnDelete:CASCADE"`
 	Attachments []Attachment `gorm:"foreignKey:DiaryID"`
@@ -66,24 +74,39 @@ type Diary struct {
 type Attachment struct {
 	ID        uint      `gorm:"primaryKey;autoIncrement"`
 	DiaryID   uint      `gorm:"not null;index"`
-	FileURL   string    `gorm:"type:text;not null"`
+	FileURL   string    `gorm:"type:text;not null" json:"-"`
 	FileName  string    `gorm:"size:255;not null"`
 	FileType  string    `gorm:"size:100"`
 	CreatedAt time.Time `gorm:"autoCreateTime"`
+	// StorageKey คือ key ของไฟล์ใน storage ว่างสำหรับไฟล์ที่อัปโหลดก่อนย้ายไปใช้ storage ซึ่งยังอยู่ที่ FileURL
+	StorageKey string `gorm:"size:255;index" json:"-"`
+	// URL คือ URL ดาวน์โหลดแบบลงนามที่หมดอายุในเวลาสั้น ๆ ไม่ได้เก็บในตาราง เติมเมื่อส่ง response
+	URL string `gorm:"-" json:"URL,omitempty"`
 
 	Diary Diary `gorm:"-:all" json:"-"`
 }
 
+// Comment คือความคิดเห็นในบันทึก ParentID ชี้ไปยังความคิดเห็นที่ตอบกลับ และ Depth คือระดับในเธรด (0 = ระดับบนสุด)
 type Comment struct {
 	ID        uint      `gorm:"primaryKey;autoIncrement"`
 	DiaryID   uint      `gorm:"not null;index"`
 	AuthorID  uint      `gorm:"not null;index"`
+	ParentID  *uint     `gorm:"index"`
+	Depth     int       `gorm:"not null;default:0"`
 	Content   string    `gorm:"type:text;not null"`
+	EditCount int       `gorm:"not null;default:0"`
+	Hidden    bool      `gorm:"not null;default:false;index"`
 	CreatedAt time.Time `gorm:"autoCreateTime"`
 	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+	EditedAt  *time.Time
+	HiddenAt  *time.Time
+	HiddenBy  *uint
+	// Reactions คือจำนวน reaction แยกตาม emoji ไม่ได้เก็บในตาราง เติมเมื่อส่ง response
+	Reactions map[string]int64 `gorm:"-" json:"Reactions,omitempty"`
 
-	Diary  Diary `gorm:"foreignKey:DiaryID;constraint:OnDelete:CASCADE"`
-	Author User  `gorm:"foreignKey:AuthorID;constraint:OnDelete:CASCADE"`
+	Diary  Diary    `gorm:"foreignKey:DiaryID;constraint:OnDelete:CASCADE"`
+	Author User     `gorm:"foreignKey:AuthorID;constraint:OnDelete:CASCADE"`
+	Parent *Comment `gorm:"foreignKey:ParentID;constraint:OnDelete:CASCADE" json:"-"`
 }
 
 type Notification struct {
@@ -94,6 +117,7 @@ type Notification struct {
 	Title     string         `gorm:"size:255;not null"`
 	Message   string         `gorm:"type:text;not null"`
 	Data      datatypes.JSON `gorm:"type:json"`
+	Priority  string         `gorm:"size:20;not null;default:normal"`
 	IsRead    bool           `gorm:"default:false"`
 	CreatedAt time.Time      `gorm:"autoCreateTime"`
 	UpdatedAt time.Time      `gorm:"autoUpdateTime"`
@@ -112,3 +136,189 @@ type AdvisorNotification struct {
 
 	Student User `gorm:"foreignKey:StudentID;references:ID;constraint:OnDelete:CASCADE"`
 }
+
+type MoodScale struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement"`
+	Key       string    `gorm:"column:mood_key;size:50;unique;not null"`
+	LabelTH   string    `gorm:"size:100;not null"`
+	LabelEN   string    `gorm:"size:100;not null"`
+	Score     int       `gorm:"not null"`
+	Color     string    `gorm:"size:20"`
+	Emoji     string    `gorm:"size:20"`
+	SortOrder int       `gorm:"not null;default:0"`
+	IsDefault bool      `gorm:"not null;default:false"`
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+}
+
+// CommentRevision เก็บเนื้อหาก่อนแก้ไขของความคิดเห็น ใช้ตรวจสอบย้อนหลังโดยอาจารย์และผู้ดูแลระบบ
+type CommentRevision struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement"`
+	CommentID uint      `gorm:"not null;index"`
+	EditorID  uint      `gorm:"not null;index"`
+	Content   string    `gorm:"type:text;not null"`
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+
+	Comment Comment `gorm:"foreignKey:CommentID;constraint:OnDelete:CASCADE" json:"-"`
+	Editor  User    `gorm:"foreignKey:EditorID;references:ID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+// CommentReport คือการรายงานความคิดเห็นที่ไม่เหมาะสม ผู้ใช้หนึ่งคนรายงานความคิดเห็นเดียวกันได้ครั้งเดียว
+type CommentReport struct {
+	ID         uint    `gorm:"primaryKey;autoIncrement"`
+	CommentID  uint    `gorm:"not null;uniqueIndex:idx_comment_report_reporter"`
+	ReporterID uint    `gorm:"not null;uniqueIndex:idx_comment_report_reporter;index"`
+	Reason     string  `gorm:"size:30;not null"`
+	Details    *string `gorm:"type:text"`
+	Status     string  `gorm:"size:20;not null;default:open;index"`
+	ReviewedBy *uint   `gorm:"index"`
+	ReviewedAt *time.Time
+	CreatedAt  time.Time `gorm:"autoCreateTime"`
+	UpdatedAt  time.Time `gorm:"autoUpdateTime"`
+
+	Comment  Comment `gorm:"foreignKey:CommentID;constraint:OnDelete:CASCADE"`
+	Reporter User    `gorm:"foreignKey:ReporterID;references:ID;constraint:OnDelete:CASCADE"`
+}
+
+// MoodAlertSetting คือเกณฑ์การแจ้งเตือนอารมณ์เชิงลบที่อาจารย์แต่ละคนตั้งค่าเอง
+type MoodAlertSetting struct {
+	ID                  uint      `gorm:"primaryKey;autoIncrement"`
+	AdvisorID           uint      `gorm:"not null;uniqueIndex"`
+	Enabled             bool      `gorm:"not null;default:true"`
+	NegativeScoreMax    int       `gorm:"not null;default:2"`
+	ConsecutiveNegative int       `gorm:"not null;default:3"`
+	BaselineDays        int       `gorm:"not null;default:14"`
+	RecentEntries       int       `gorm:"not null;default:3"`
+	DropThreshold       float64   `gorm:"not null;default:1.5"`
+	SilenceDays         int       `gorm:"not null;default:3"`
+	CooldownDays        int       `gorm:"not null;default:7"`
+	CreatedAt           time.Time `gorm:"autoCreateTime"`
+	UpdatedAt           time.Time `gorm:"autoUpdateTime"`
+
+	Advisor User `gorm:"foreignKey:AdvisorID;references:ID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+// MoodAlert บันทึกการแจ้งเตือนที่ส่งไปแล้ว เพื่อไม่ให้แจ้งเตือนซ้ำภายในช่วง cooldown
+type MoodAlert struct {
+	ID             uint      `gorm:"primaryKey;autoIncrement"`
+	AdvisorID      uint      `gorm:"not null;index:idx_mood_alert_lookup"`
+	StudentID      uint      `gorm:"not null;index:idx_mood_alert_lookup"`
+	Rule           string    `gorm:"size:50;not null;index:idx_mood_alert_lookup"`
+	DiaryID        *uint     `gorm:"index"`
+	NotificationID *uint     `gorm:"index"`
+	CreatedAt      time.Time `gorm:"autoCreateTime"`
+
+	Advisor User `gorm:"foreignKey:AdvisorID;references:ID;constraint:OnDelete:CASCADE" json:"-"`
+	Student User `gorm:"foreignKey:StudentID;references:ID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+// RiskKeyword คือคำหรือวลีที่บ่งชี้ความเสี่ยง ผู้ดูแลระบบจัดการได้ Normalized คือรูปที่ใช้ค้นหา
+type RiskKeyword struct {
+	ID         uint      `gorm:"primaryKey;autoIncrement"`
+	Phrase     string    `gorm:"size:255;not null"`
+	Normalized string    `gorm:"size:255;uniqueIndex;not null"`
+	Severity   string    `gorm:"size:20;not null;default:high"`
+	IsActive   bool      `gorm:"not null;default:true"`
+	CreatedAt  time.Time `gorm:"autoCreateTime"`
+	UpdatedAt  time.Time `gorm:"autoUpdateTime"`
+}
+
+// DiaryRiskFlag คือการพบคำเสี่ยงในบันทึก ใช้ติดตามโดยผู้ดูแลระบบ
+type DiaryRiskFlag struct {
+	ID            uint   `gorm:"primaryKey;autoIncrement"`
+	DiaryID       uint   `gorm:"not null;uniqueIndex:idx_diary_risk_keyword"`
+	KeywordID     uint   `gorm:"not null;uniqueIndex:idx_diary_risk_keyword"`
+	StudentID     uint   `gorm:"not null;index"`
+	MatchedPhrase string `gorm:"size:255;not null"`
+	Severity      string `gorm:"size:20;not null"`
+	Status        string `gorm:"size:20;not null;default:open;index"`
+	ReviewedBy    *uint  `gorm:"index"`
+	ReviewedAt    *time.Time
+	CreatedAt     time.Time `gorm:"autoCreateTime"`
+	UpdatedAt     time.Time `gorm:"autoUpdateTime"`
+
+	Diary   Diary       `gorm:"foreignKey:DiaryID;constraint:OnDelete:CASCADE" json:"-"`
+	Keyword RiskKeyword `gorm:"foreignKey:KeywordID;constraint:OnDelete:RESTRICT" json:"-"`
+	Student User        `gorm:"foreignKey:StudentID;references:ID;constraint:OnDelete:CASCADE"`
+}
+
+// DigestSetting คือการสมัครรับสรุปรายสัปดาห์ทางอีเมลของอาจารย์ Weekday ใช้ 0 = อาทิตย์ ถึง 6 = เสาร์
+// และ Hour เป็นชั่วโมงตามเวลาประเทศไทย
+type DigestSetting struct {
+	ID         uint `gorm:"primaryKey;autoIncrement"`
+	AdvisorID  uint `gorm:"not null;uniqueIndex"`
+	Enabled    bool `gorm:"not null;default:false"`
+	Weekday    int  `gorm:"not null;default:1"`
+	Hour       int  `gorm:"not null;default:8"`
+	LastSentAt *time.Time
+	CreatedAt  time.Time `gorm:"autoCreateTime"`
+	UpdatedAt  time.Time `gorm:"autoUpdateTime"`
+
+	Advisor User `gorm:"foreignKey:AdvisorID;references:ID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+// Mention คือการกล่าวถึงผู้ใช้ด้วย @ชื่อ ในบันทึก (CommentID เป็น nil) หรือในความคิดเห็น
+type Mention struct {
+	ID          uint      `gorm:"primaryKey;autoIncrement"`
+	UserID      uint      `gorm:"not null;index"`
+	MentionedBy uint      `gorm:"not null;index"`
+	DiaryID     uint      `gorm:"not null;index"`
+	CommentID   *uint     `gorm:"index"`
+	CreatedAt   time.Time `gorm:"autoCreateTime"`
+
+	User    User     `gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE" json:"-"`
+	Author  User     `gorm:"foreignKey:MentionedBy;references:ID;constraint:OnDelete:CASCADE"`
+	Diary   Diary    `gorm:"foreignKey:DiaryID;constraint:OnDelete:CASCADE" json:"-"`
+	Comment *Comment `gorm:"foreignKey:CommentID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+// Reaction คือการตอบสนองด้วย emoji ต่อบันทึกหรือความคิดเห็น ผู้ใช้หนึ่งคนมีได้หนึ่ง reaction ต่อหนึ่งรายการ
+type Reaction struct {
+	ID         uint      `gorm:"primaryKey;autoIncrement"`
+	UserID     uint      `gorm:"not null;uniqueIndex:idx_reaction_user_target"`
+	TargetType string    `gorm:"size:20;not null;uniqueIndex:idx_reaction_user_target;index:idx_reaction_target"`
+	TargetID   uint      `gorm:"not null;uniqueIndex:idx_reaction_user_target;index:idx_reaction_target"`
+	Emoji      string    `gorm:"size:32;not null"`
+	CreatedAt  time.Time `gorm:"autoCreateTime"`
+	UpdatedAt  time.Time `gorm:"autoUpdateTime"`
+
+	User User `gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE"`
+}
+
+// DiaryRead คือสถานะการอ่านบันทึกของผู้ใช้หนึ่งคน ReadVersion คือ Diary.Version ที่อ่านล่าสุด
+// ใช้ตรวจว่าอ่านหลังการแก้ไขครั้งล่าสุดแล้วหรือไม่
+type DiaryRead struct {
+	ID          uint      `gorm:"primaryKey;autoIncrement"`
+	DiaryID     uint      `gorm:"not null;uniqueIndex:idx_diary_read_user"`
+	UserID      uint      `gorm:"not null;uniqueIndex:idx_diary_read_user;index"`
+	ReadVersion uint      `gorm:"not null"`
+	FirstReadAt time.Time `gorm:"not null"`
+	LastReadAt  time.Time `gorm:"not null"`
+	// ReadLatest เป็น true เมื่ออ่านบันทึกฉบับล่าสุดแล้ว ไม่ได้เก็บในตาราง
+	ReadLatest bool `gorm:"-"`
+
+	User  User  `gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE"`
+	Diary Diary `gorm:"foreignKey:DiaryID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+// AdvisorNote คือบันทึกส่วนตัวของอาจารย์เกี่ยวกับนิสิต นิสิตไม่สามารถเห็นได้
+// ผูกกับความสัมพันธ์ StudentAdvisor จึงถูกลบเมื่อยกเลิกการเป็นอาจารย์ที่ปรึกษา
+// Shared เป็น true เมื่อแบ่งปันให้อาจารย์ที่ปรึกษาคนอื่นของนิสิตคนเดียวกัน
+type AdvisorNote struct {
+	ID               uint           `gorm:"primaryKey;autoIncrement"`
+	StudentAdvisorID uint           `gorm:"not null;index"`
+	AuthorID         uint           `gorm:"not null;index"`
+	StudentID        uint           `gorm:"not null;index"`
+	DiaryID          *uint          `gorm:"index"`
+	Title            string         `gorm:"size:255;not null"`
+	ContentHTML      string         `gorm:"type:text"`
+	ContentDelta     datatypes.JSON `gorm:"type:json"`
+	Shared           bool           `gorm:"not null;default:false"`
+	CreatedAt        time.Time      `gorm:"autoCreateTime"`
+	UpdatedAt        time.Time      `gorm:"autoUpdateTime"`
+
+	StudentAdvisor StudentAdvisor `gorm:"foreignKey:StudentAdvisorID;constraint:OnDelete:CASCADE" json:"-"`
+	Author         User           `gorm:"foreignKey:AuthorID;references:ID;constraint:OnDelete:CASCADE"`
+	Student        User           `gorm:"foreignKey:StudentID;references:ID;constraint:OnDelete:CASCADE" json:"-"`
+	Diary          *Diary         `gorm:"foreignKey:DiaryID;constraint:OnDelete:SET NULL" json:"-"`
+}
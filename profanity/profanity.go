@@ -0,0 +1,112 @@
+package profanity
+
+import (
+	"bufio"
+	_ "embed"
+	"gofiber-auth/risk"
+	"os"
+	"strings"
+	"sync"
+)
+
+//go:embed words.txt
+var defaultWords string
+
+// Filter ตรวจหาคำไม่สุภาพในข้อความภาษาไทยและภาษาอังกฤษ
+// การจับคู่ใช้ risk.Normalize จึงรองรับการพิมพ์ซ้ำตัวอักษร การเว้นวรรคในคำไทย และคำต่อท้ายภาษาอังกฤษ
+type Filter struct {
+	words []word
+}
+
+type word struct {
+	original   string
+	normalized string
+}
+
+// New สร้าง Filter จากรายการคำ คำที่ว่างหลัง Normalize จะถูกข้าม
+func New(words []string) *Filter {
+	f := &Filter{}
+	seen := map[string]bool{}
+	for _, w := range words {
+		normalized := risk.Normalize(w)
+		if normalized == "" || seen[normalized] {
+			continue
+		}
+		seen[normalized] = true
+		f.words = append(f.words, word{original: strings.TrimSpace(w), normalized: normalized})
+	}
+	return f
+}
+
+// Find คืนค่าคำไม่สุภาพที่พบในข้อความ ตามรูปที่กำหนดไว้ในรายการคำ
+func (f *Filter) Find(text string) []string {
+	if f == nil || len(f.words) == 0 {
+		return nil
+	}
+	normalized := risk.Normalize(text)
+	var found []string
+	for _, w := range f.words {
+		if risk.Contains(normalized, w.normalized) {
+			found = append(found, w.original)
+		}
+	}
+	return found
+}
+
+// FromEnv สร้าง Filter จากรายการคำเริ่มต้นรวมกับ PROFANITY_WORDS (คั่นด้วยจุลภาค)
+// ตั้ง PROFANITY_FILTER=off เพื่อปิดการกรอง
+func FromEnv() *Filter {
+	if strings.EqualFold(os.Getenv("PROFANITY_FILTER"), "off") {
+		return New(nil)
+	}
+
+	var words []string
+	scanner := bufio.NewScanner(strings.NewReader(defaultWords))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		words = append(words, line)
+	}
+	for _, w := range strings.Split(os.Getenv("PROFANITY_WORDS"), ",") {
+		if w = strings.TrimSpace(w); w != "" {
+			words = append(words, w)
+		}
+	}
+	return New(words)
+}
+
+var (
+	mu      sync.RWMutex
+	current *Filter
+)
+
+// SetDefault เปลี่ยน Filter ที่ใช้โดย Find
+func SetDefault(f *Filter) {
+	mu.Lock()
+	current = f
+	mu.Unlock()
+}
+
+// Default คืนค่า Filter ปัจจุบัน โดยสร้างจาก environment ในครั้งแรกที่เรียก
+func Default() *Filter {
+	mu.RLock()
+	f := current
+	mu.RUnlock()
+	if f != nil {
+		return f
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if current == nil {
+		current = FromEnv()
+	}
+	return current
+}
+
+// Find ตรวจข้อความด้วย Filter ปัจจุบัน
+func Find(text string) []string {
+	return Default().Find(text)
+}
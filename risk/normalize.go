@@ -0,0 +1,125 @@
+package risk
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Normalize แปลงข้อความให้อยู่ในรูปมาตรฐานสำหรับค้นหาวลี
+//   - ตัวพิมพ์เล็ก และตัดคำต่อท้ายของคำภาษาอังกฤษให้เหลือรูปพื้นฐาน
+//   - ลบช่องว่างระหว่างอักษรไทย เพราะภาษาไทยไม่เว้นวรรคระหว่างคำ
+//   - ยุบอักษรที่พิมพ์ซ้ำตั้งแต่สามตัวขึ้นไป เช่น "ตายยยย" เป็น "ตาย"
+//   - เครื่องหมายวรรคตอนถือเป็นช่องว่าง และคำคั่นด้วยช่องว่างเดียว
+func Normalize(text string) string {
+	runes := collapseRepeats([]rune(strings.ToLower(text)))
+
+	var b strings.Builder
+	var word []rune
+	flushWord := func() {
+		if len(word) > 0 {
+			b.WriteString(stem(string(word)))
+			b.WriteByte(' ')
+			word = word[:0]
+		}
+	}
+
+	lastThai := false
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case isThai(r):
+			flushWord()
+			if r == 'ๆ' {
+				continue
+			}
+			b.WriteRune(r)
+			lastThai = true
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '\'':
+			if lastThai {
+				b.WriteByte(' ')
+				lastThai = false
+			}
+			word = append(word, r)
+		default:
+			flushWord()
+			// ช่องว่างระหว่างอักษรไทยสองส่วนถูกตัดทิ้ง ส่วนอื่นถือเป็นตัวคั่นคำ
+			if lastThai && unicode.IsSpace(r) && nextNonSpaceIsThai(runes, i) {
+				continue
+			}
+			if lastThai {
+				b.WriteByte(' ')
+				lastThai = false
+			}
+		}
+	}
+	flushWord()
+	if lastThai {
+		b.WriteByte(' ')
+	}
+
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// Contains ตรวจว่าข้อความที่ผ่าน Normalize มีวลีที่ผ่าน Normalize แล้วอยู่หรือไม่
+// วลีภาษาอังกฤษต้องตรงทั้งคำ ส่วนวลีภาษาไทยค้นหาแบบ substring
+func Contains(normalizedText, normalizedPhrase string) bool {
+	if normalizedPhrase == "" {
+		return false
+	}
+	if hasThai(normalizedPhrase) {
+		return strings.Contains(normalizedText, normalizedPhrase)
+	}
+	return strings.Contains(" "+normalizedText+" ", " "+normalizedPhrase+" ")
+}
+
+func isThai(r rune) bool {
+	return r >= 0x0E00 && r <= 0x0E7F
+}
+
+func hasThai(s string) bool {
+	for _, r := range s {
+		if isThai(r) {
+			return true
+		}
+	}
+	return false
+}
+
+func nextNonSpaceIsThai(runes []rune, i int) bool {
+	for j := i + 1; j < len(runes); j++ {
+		if !unicode.IsSpace(runes[j]) {
+			return isThai(runes[j])
+		}
+	}
+	return false
+}
+
+// collapseRepeats ยุบอักษรเดียวกันที่ติดกันตั้งแต่สามตัวให้เหลือตัวเดียว
+func collapseRepeats(runes []rune) []rune {
+	out := make([]rune, 0, len(runes))
+	for i := 0; i < len(runes); {
+		j := i
+		for j < len(runes) && runes[j] == runes[i] {
+			j++
+		}
+		if j-i >= 3 {
+			out = append(out, runes[i])
+		} else {
+			out = append(out, runes[i:j]...)
+		}
+		i = j
+	}
+	return out
+}
+
+// stem ตัดคำต่อท้ายพื้นฐานของภาษาอังกฤษ เช่น "hurting" -> "hurt", "cuts" -> "cut"
+func stem(word string) string {
+	word = strings.Trim(word, "'")
+	word = strings.TrimSuffix(word, "'s")
+	for _, suffix := range []string{"ing", "ed", "es", "s", "ly"} {
+		if s := strings.TrimSuffix(word, suffix); s != word && len([]rune(s)) >= 3 {
+			return s
+		}
+	}
+	return word
+}
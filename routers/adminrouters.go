@@ -9,4 +9,30 @@ import (
 func AdminRouter(app *fiber.App) {
 	app.Get("/api/admin/allstudent", controllers.GetAllStudentsByAdmin)
 	app.Get("/api/admin/allteachers", controllers.GetAllTeacherByAdmin)
+
+	moodScale := app.Group("/api/admin/mood-scale", controllers.AuthMiddleware, controllers.RequireRole("admin"))
+	moodScale.Post("/", controllers.CreateMoodScale)
+	moodScale.Put("/:id", controllers.UpdateMoodScale)
+	moodScale.Delete("/:id", controllers.DeleteMoodScale)
+
+	riskKeywords := app.Group("/api/admin/risk-keywords", controllers.AuthMiddleware, controllers.RequireRole("admin"))
+	riskKeywords.Get("/", controllers.GetRiskKeywords)
+	riskKeywords.Post("/", controllers.CreateRiskKeyword)
+	riskKeywords.Put("/:id", controllers.UpdateRiskKeyword)
+	riskKeywords.Delete("/:id", controllers.DeleteRiskKeyword)
+
+	riskFlags := app.Group("/api/admin/risk-flags", controllers.AuthMiddleware, controllers.RequireRole("admin"))
+	riskFlags.Get("/", controllers.GetRiskFlags)
+	riskFlags.Patch("/:id", controllers.UpdateRiskFlag)
+
+	commentReports := app.Group("/api/admin/comment-reports", controllers.AuthMiddleware, controllers.RequireRole("admin"))
+	commentReports.Get("/", controllers.GetCommentReports)
+	commentReports.Patch("/:id", controllers.UpdateCommentReport)
+
+	comments := app.Group("/api/admin/comments", controllers.AuthMiddleware, controllers.RequireRole("admin"))
+	comments.Post("/:id/hide", controllers.HideComment)
+	comments.Post("/:id/unhide", controllers.UnhideComment)
+
+	analytics := app.Group("/api/admin/analytics", controllers.AuthMiddleware, controllers.RequireRole("admin"))
+	analytics.Get("/response-times", controllers.GetResponseTimeStats)
 }
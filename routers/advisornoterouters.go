@@ -0,0 +1,16 @@
+package routers
+
+import (
+	"gofiber-auth/controllers"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func AdvisorNoteRouter(app *fiber.App) {
+	notes := app.Group("/api/advisor-notes", controllers.AuthMiddleware, controllers.RequireRole("advisor"))
+	notes.Get("/", controllers.GetAdvisorNotes)
+	notes.Get("/:id", controllers.GetAdvisorNote)
+	notes.Post("/", controllers.CreateAdvisorNote)
+	notes.Put("/:id", controllers.UpdateAdvisorNote)
+	notes.Delete("/:id", controllers.DeleteAdvisorNote)
+}
@@ -7,7 +7,9 @@ import (
 )
 
 func AttachmentRouter(app *fiber.App) {
-	app.Get("/api/diary/file", controllers.GetAttachmentsByDiaryId)
-	app.Post("/api/diary/uploadfile", controllers.UploadAttachment)
-	app.Delete("/api/diary/attachment/:id", controllers.DeleteAttachment)
+	app.Get("/api/diary/file", controllers.AuthMiddleware, controllers.GetAttachmentsByDiaryId)
+	app.Post("/api/diary/uploadfile", controllers.AuthMiddleware, controllers.UploadAttachment)
+	app.Get("/api/diary/attachment/:id/url", controllers.AuthMiddleware, controllers.GetAttachmentURL)
+	app.Get("/api/diary/attachment/:id/download", controllers.OptionalAuthMiddleware, controllers.DownloadAttachment)
+	app.Delete("/api/diary/attachment/:id", controllers.AuthMiddleware, controllers.DeleteAttachment)
 }
@@ -7,7 +7,11 @@ import (
 )
 
 func CommentRouter(app *fiber.App) {
-	app.Post("/api/comment", controllers.CreateNewComment)
-	app.Get("/api/comment", controllers.GetCommentByDiaryId)
-	app.Delete("/api/comment/:id", controllers.DeleteComment)
+	app.Post("/api/comment", controllers.AuthMiddleware, controllers.CreateNewComment)
+	app.Get("/api/comment", controllers.AuthMiddleware, controllers.GetCommentByDiaryId)
+	app.Get("/api/comment/:id/replies", controllers.AuthMiddleware, controllers.GetCommentReplies)
+	app.Get("/api/comment/:id/history", controllers.AuthMiddleware, controllers.RequireRole("advisor", "admin"), controllers.GetCommentHistory)
+	app.Put("/api/comment/:id", controllers.AuthMiddleware, controllers.UpdateComment)
+	app.Post("/api/comment/:id/report", controllers.AuthMiddleware, controllers.ReportComment)
+	app.Delete("/api/comment/:id", controllers.AuthMiddleware, controllers.DeleteComment)
 }
@@ -0,0 +1,11 @@
+package routers
+
+import (
+	"gofiber-auth/controllers"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func DashboardRouter(app *fiber.App) {
+	app.Get("/api/dashboard/advisor", controllers.AuthMiddleware, controllers.RequireRole("advisor", "admin"), controllers.GetAdvisorDashboard)
+}
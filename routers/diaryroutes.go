@@ -7,10 +7,12 @@ import (
 )
 
 func DiaryRouter(app *fiber.App) {
-	app.Post("/api/diary", controllers.CreateNewDiary)
-	app.Get("/api/diary/", controllers.GetDiaryByDate)
+	app.Post("/api/diary", controllers.AuthMiddleware, controllers.RequireRole("student"), controllers.CreateNewDiary)
+	app.Get("/api/diary/", controllers.AuthMiddleware, controllers.GetDiaryByDate)
+	app.Get("/api/diary/unread", controllers.AuthMiddleware, controllers.RequireRole("advisor"), controllers.GetUnreadDiaries)
 	app.Get("/api/diary/by-student", controllers.GetDiaryDateByStudentId)
-	app.Patch("/api/diary/:id", controllers.PatchDiary)
-	app.Put("/api/diary/:id", controllers.UpdateDiary)
-	app.Delete("/api/diary/:id", controllers.DeleteDiary)
+	app.Get("/api/diary/:id<int>", controllers.AuthMiddleware, controllers.GetDiaryById)
+	app.Patch("/api/diary/:id", controllers.AuthMiddleware, controllers.PatchDiary)
+	app.Put("/api/diary/:id", controllers.AuthMiddleware, controllers.UpdateDiary)
+	app.Delete("/api/diary/:id", controllers.AuthMiddleware, controllers.DeleteDiary)
 }
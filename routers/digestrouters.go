@@ -0,0 +1,14 @@
+package routers
+
+import (
+	"gofiber-auth/controllers"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func DigestRouter(app *fiber.App) {
+	digest := app.Group("/api/digest", controllers.AuthMiddleware)
+	digest.Get("/settings", controllers.RequireRole("advisor"), controllers.GetDigestSetting)
+	digest.Put("/settings", controllers.RequireRole("advisor"), controllers.UpdateDigestSetting)
+	digest.Get("/preview", controllers.RequireRole("advisor", "admin"), controllers.PreviewWeeklyDigest)
+}
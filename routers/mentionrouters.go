@@ -0,0 +1,11 @@
+package routers
+
+import (
+	"gofiber-auth/controllers"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func MentionRouter(app *fiber.App) {
+	app.Get("/api/mentions", controllers.AuthMiddleware, controllers.GetMyMentions)
+}
@@ -0,0 +1,13 @@
+package routers
+
+import (
+	"gofiber-auth/controllers"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func MoodAlertRouter(app *fiber.App) {
+	alerts := app.Group("/api/mood-alert", controllers.AuthMiddleware, controllers.RequireRole("advisor", "admin"))
+	alerts.Get("/settings", controllers.GetMoodAlertSetting)
+	alerts.Put("/settings", controllers.UpdateMoodAlertSetting)
+}
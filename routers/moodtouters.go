@@ -7,5 +7,9 @@ import (
 )
 
 func MoodRouter(app *fiber.App) {
-	app.Get("/api/mood", controllers.GetMoodByAdvisor)
+	app.Get("/api/mood", controllers.AuthMiddleware, controllers.RequireRole("advisor", "admin"), controllers.GetMoodByAdvisor)
+	app.Get("/api/mood/scale", controllers.GetMoodScale)
+	app.Get("/api/mood/series/student", controllers.AuthMiddleware, controllers.GetStudentMoodSeries)
+	app.Get("/api/mood/series/group", controllers.AuthMiddleware, controllers.RequireRole("advisor", "admin"), controllers.GetGroupMoodSeries)
+	app.Get("/api/mood/series/advisor", controllers.AuthMiddleware, controllers.RequireRole("advisor", "admin"), controllers.GetAdvisorMoodSeries)
 }
@@ -0,0 +1,15 @@
+package routers
+
+import (
+	"gofiber-auth/controllers"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func ReactionRouter(app *fiber.App) {
+	reactions := app.Group("/api/reactions", controllers.AuthMiddleware)
+	reactions.Get("/emojis", controllers.GetReactionEmojis)
+	reactions.Get("/", controllers.GetReactions)
+	reactions.Put("/", controllers.SetReaction)
+	reactions.Delete("/", controllers.DeleteReaction)
+}
@@ -0,0 +1,12 @@
+package routers
+
+import (
+	"gofiber-auth/controllers"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func ReportRouter(app *fiber.App) {
+	reports := app.Group("/api/reports", controllers.AuthMiddleware, controllers.RequireRole("advisor", "admin"))
+	reports.Get("/:report", controllers.ExportReport)
+}
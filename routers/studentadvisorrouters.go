@@ -12,5 +12,6 @@ func StudentAdvisorRouter(app *fiber.App) {
 	app.Post("/api/student-advisor", controllers.CreateStudentAdvisor)
 	app.Patch("/api/student-advisor", controllers.ApproveAdvisorRequest)
 	app.Get("/api/student-advisor", controllers.GetAdvisorRequests)
+	app.Get("/api/student-advisor/inactive", controllers.AuthMiddleware, controllers.RequireRole("advisor", "admin"), controllers.GetInactiveStudentsByAdvisor)
 	app.Delete("/api/student-advisor", controllers.UnApproveAdvisorRequest)
 }
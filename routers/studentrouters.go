@@ -9,5 +9,6 @@ import (
 func StudentRouter(app *fiber.App) {
 	app.Get("/api/students", controllers.GetAllStudents)
 	app.Get("/api/student", controllers.GetStudentById)
+	app.Get("/api/student/stats", controllers.AuthMiddleware, controllers.GetStudentStats)
 	app.Post("/api/student", controllers.CreateStudent)
 }
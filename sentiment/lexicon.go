@@ -0,0 +1,152 @@
+package sentiment
+
+import (
+	"bufio"
+	_ "embed"
+	"math"
+	"strconv"
+	"strings"
+)
+
+//go:embed lexicon_en.txt
+var lexiconEN string
+
+//go:embed lexicon_th.txt
+var lexiconTH string
+
+// คำปฏิเสธจะกลับขั้วคะแนนของคำที่มีความรู้สึกซึ่งตามมาภายใน negationWindow คำ
+var negators = []string{
+	"not", "no", "never", "don't", "dont", "didn't", "isn't", "wasn't", "can't", "cannot", "won't", "without",
+	"ไม่", "ไม่ได้", "ไม่ค่อย", "ไม่เคย", "ไม่มี", "ไม่ค่อยจะ",
+}
+
+// คำขยายที่อยู่หน้าคำ (ภาษาอังกฤษ) และหลังคำ (ภาษาไทย) เพิ่มน้ำหนักของคำที่อยู่ติดกัน
+var (
+	preIntensifiers  = []string{"very", "really", "so", "extremely", "too", "super", "totally", "แสน", "โคตร", "สุด"}
+	postIntensifiers = []string{"มาก", "มากๆ", "สุดๆ", "จัง", "เหลือเกิน", "ที่สุด"}
+)
+
+const (
+	negationWindow   = 3
+	negationFactor   = -0.75
+	intensifierBoost = 1.5
+	// normalizeAlpha ใช้ปรับผลรวมคะแนนให้อยู่ในช่วง -1 ถึง 1 แบบเดียวกับ VADER
+	normalizeAlpha = 15
+)
+
+// LexiconAnalyzer วิเคราะห์ความรู้สึกจากคะแนนของคำใน lexicon ภาษาไทยและภาษาอังกฤษ
+type LexiconAnalyzer struct {
+	scores map[string]float64
+	dict   map[string]bool
+	neg    map[string]bool
+	pre    map[string]bool
+	post   map[string]bool
+}
+
+// NewLexiconAnalyzer สร้าง analyzer จาก lexicon ที่ฝังมากับโปรแกรม
+func NewLexiconAnalyzer() *LexiconAnalyzer {
+	a := &LexiconAnalyzer{
+		scores: make(map[string]float64),
+		dict:   make(map[string]bool),
+		neg:    toSet(negators),
+		pre:    toSet(preIntensifiers),
+		post:   toSet(postIntensifiers),
+	}
+	a.load(lexiconEN)
+	a.load(lexiconTH)
+	for _, words := range [][]string{negators, preIntensifiers, postIntensifiers} {
+		for _, w := range words {
+			a.dict[w] = true
+		}
+	}
+	return a
+}
+
+// AddWord เพิ่มหรือแทนที่คะแนนของคำ ใช้ขยาย lexicon ตามบริบทของหน่วยงาน
+func (a *LexiconAnalyzer) AddWord(word string, score float64) {
+	word = strings.ToLower(strings.TrimSpace(word))
+	a.scores[word] = score
+	a.dict[word] = true
+}
+
+func (a *LexiconAnalyzer) load(data string) {
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		score, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil {
+			continue
+		}
+		a.AddWord(fields[0], score)
+	}
+}
+
+func (a *LexiconAnalyzer) lookup(token string) (float64, bool) {
+	if score, ok := a.scores[token]; ok {
+		return score, true
+	}
+	for _, stem := range stemEnglish(token) {
+		if score, ok := a.scores[stem]; ok {
+			return score, true
+		}
+	}
+	return 0, false
+}
+
+func (a *LexiconAnalyzer) Analyze(text string) Result {
+	tokens := Tokenize(text, a.dict)
+	result := Result{Language: detectLanguage(text)}
+
+	sum := 0.0
+	for i, token := range tokens {
+		if token == Boundary {
+			continue
+		}
+		result.Tokens++
+
+		score, ok := a.lookup(token)
+		if !ok {
+			continue
+		}
+
+		if i > 0 && a.pre[tokens[i-1]] || i+1 < len(tokens) && a.post[tokens[i+1]] {
+			score *= intensifierBoost
+		}
+		for j := i - 1; j >= 0 && j >= i-negationWindow; j-- {
+			if tokens[j] == Boundary {
+				break
+			}
+			if a.neg[tokens[j]] {
+				score *= negationFactor
+				break
+			}
+		}
+
+		if score > 0 {
+			result.Positive++
+		} else if score < 0 {
+			result.Negative++
+		}
+		sum += score
+	}
+
+	if sum != 0 {
+		result.Score = sum / math.Sqrt(sum*sum+normalizeAlpha)
+	}
+	return result
+}
+
+func toSet(words []string) map[string]bool {
+	set := make(map[string]bool, len(words))
+	for _, w := range words {
+		set[w] = true
+	}
+	return set
+}
@@ -0,0 +1,135 @@
+package sentiment
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxThaiWordRunes คือความยาวสูงสุดของคำในพจนานุกรมที่ใช้ตัดคำภาษาไทย
+const maxThaiWordRunes = 20
+
+func isThai(r rune) bool {
+	return r >= 0x0E00 && r <= 0x0E7F
+}
+
+func isLatin(r rune) bool {
+	return r < unicode.MaxLatin1 && unicode.IsLetter(r) || r == '\''
+}
+
+// Boundary คือ token ที่คั่นระหว่างวลีในผลของ Tokenize
+const Boundary = ""
+
+// Tokenize แยกข้อความเป็นคำ ส่วนที่เป็นภาษาไทยตัดคำด้วย maximal matching กับ dict
+// ส่วนที่เป็นภาษาอังกฤษแยกตามช่องว่างและเครื่องหมาย และแปลงเป็นตัวพิมพ์เล็ก
+// ระหว่างวลีจะมี Boundary คั่นอยู่
+func Tokenize(text string, dict map[string]bool) []string {
+	var tokens []string
+	runes := []rune(text)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case isThai(r):
+			j := i
+			for j < len(runes) && isThai(runes[j]) {
+				j++
+			}
+			tokens = append(tokens, segmentThai(runes[i:j], dict)...)
+			i = j
+		case isLatin(r):
+			j := i
+			for j < len(runes) && isLatin(runes[j]) {
+				j++
+			}
+			if word := strings.Trim(strings.ToLower(string(runes[i:j])), "'"); word != "" {
+				tokens = append(tokens, word)
+			}
+			i = j
+		default:
+			// เครื่องหมายวรรคตอนและช่องว่างหลังภาษาไทยคือการจบวลี ใช้จำกัดขอบเขตของคำปฏิเสธ
+			boundary := unicode.IsPunct(r) || r == '\n' || unicode.IsSpace(r) && i > 0 && isThai(runes[i-1])
+			if boundary && len(tokens) > 0 && tokens[len(tokens)-1] != Boundary {
+				tokens = append(tokens, Boundary)
+			}
+			i++
+		}
+	}
+	return tokens
+}
+
+// segmentThai ตัดคำภาษาไทยแบบเลือกคำที่ยาวที่สุดในพจนานุกรมก่อน
+// อักษรที่ไม่ตรงกับคำใดจะถูกรวมเป็นคำที่ไม่รู้จักจนถึงตำแหน่งที่มีคำในพจนานุกรมเริ่มต้น
+func segmentThai(runes []rune, dict map[string]bool) []string {
+	var tokens []string
+	unknownStart := -1
+	flushUnknown := func(end int) {
+		if unknownStart >= 0 {
+			tokens = append(tokens, string(runes[unknownStart:end]))
+			unknownStart = -1
+		}
+	}
+
+	for i := 0; i < len(runes); {
+		if n := longestMatch(runes[i:], dict); n > 0 {
+			flushUnknown(i)
+			tokens = append(tokens, string(runes[i:i+n]))
+			i += n
+			continue
+		}
+		if unknownStart < 0 {
+			unknownStart = i
+		}
+		i++
+	}
+	flushUnknown(len(runes))
+	return tokens
+}
+
+func longestMatch(runes []rune, dict map[string]bool) int {
+	limit := len(runes)
+	if limit > maxThaiWordRunes {
+		limit = maxThaiWordRunes
+	}
+	for n := limit; n > 0; n-- {
+		if dict[string(runes[:n])] {
+			return n
+		}
+	}
+	return 0
+}
+
+// detectLanguage คืนค่า "th", "en", "mixed" หรือ "" ตามสัดส่วนอักษรไทยและละติน
+func detectLanguage(text string) string {
+	var thai, latin int
+	for len(text) > 0 {
+		r, size := utf8.DecodeRuneInString(text)
+		text = text[size:]
+		switch {
+		case isThai(r):
+			thai++
+		case r != '\'' && isLatin(r):
+			latin++
+		}
+	}
+	switch {
+	case thai == 0 && latin == 0:
+		return ""
+	case latin == 0 || thai > latin*4:
+		return "th"
+	case thai == 0 || latin > thai*4:
+		return "en"
+	}
+	return "mixed"
+}
+
+// stemEnglish ตัดคำต่อท้ายพื้นฐานของภาษาอังกฤษเพื่อให้ตรงกับรูปคำใน lexicon
+func stemEnglish(word string) []string {
+	candidates := []string{word}
+	word = strings.TrimSuffix(word, "'s")
+	for _, suffix := range []string{"ness", "ing", "ed", "ly", "es", "s"} {
+		if stem := strings.TrimSuffix(word, suffix); stem != word && len(stem) >= 3 {
+			candidates = append(candidates, stem, stem+"e")
+		}
+	}
+	return candidates
+}
@@ -0,0 +1,52 @@
+package sentiment
+
+import "sync"
+
+// Result คือผลการวิเคราะห์ความรู้สึกของข้อความหนึ่ง
+// Score อยู่ในช่วง -1 (ลบมาก) ถึง 1 (บวกมาก)
+type Result struct {
+	Score    float64 `json:"score"`
+	Positive int     `json:"positive"`
+	Negative int     `json:"negative"`
+	Tokens   int     `json:"tokens"`
+	Language string  `json:"language"`
+}
+
+// Analyzer คือตัววิเคราะห์ความรู้สึกที่เปลี่ยนได้ เช่น lexicon ภายในหรือบริการภายนอก
+type Analyzer interface {
+	Analyze(text string) Result
+}
+
+var (
+	mu      sync.RWMutex
+	current Analyzer
+)
+
+// SetDefault เปลี่ยน Analyzer ที่ใช้โดย Analyze
+func SetDefault(a Analyzer) {
+	mu.Lock()
+	current = a
+	mu.Unlock()
+}
+
+// Default คืนค่า Analyzer ปัจจุบัน โดยใช้ lexicon ภายในหากยังไม่ได้ตั้งค่า
+func Default() Analyzer {
+	mu.RLock()
+	a := current
+	mu.RUnlock()
+	if a != nil {
+		return a
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if current == nil {
+		current = NewLexiconAnalyzer()
+	}
+	return current
+}
+
+// Analyze วิเคราะห์ข้อความด้วย Analyzer ปัจจุบัน
+func Analyze(text string) Result {
+	return Default().Analyze(text)
+}
@@ -0,0 +1,91 @@
+package storage
+
+import (
+	"io"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// Local เก็บไฟล์บนดิสก์ภายใต้ Root ใช้ได้เฉพาะเมื่อรันเซิร์ฟเวอร์เครื่องเดียวหรือใช้ดิสก์ร่วมกัน
+type Local struct {
+	Root string
+}
+
+func (l *Local) path(key string) (string, error) {
+	cleaned, err := cleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(l.Root, filepath.FromSlash(cleaned)), nil
+}
+
+// Put เขียนลงไฟล์ชั่วคราวก่อนแล้วจึงเปลี่ยนชื่อ เพื่อไม่ให้ผู้อ่านเห็นไฟล์ที่เขียนไม่ครบ
+func (l *Local) Put(key string, r io.Reader, size int64, contentType string) error {
+	target, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), os.ModePerm); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".upload-*")
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), target); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
+
+func (l *Local) Open(key string) (io.ReadCloser, Info, error) {
+	target, err := l.path(key)
+	if err != nil {
+		return nil, Info{}, err
+	}
+	f, err := os.Open(target)
+	if os.IsNotExist(err) {
+		return nil, Info{}, ErrNotExist
+	}
+	if err != nil {
+		return nil, Info{}, err
+	}
+	stat, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, Info{}, err
+	}
+	return f, Info{Size: stat.Size(), ContentType: mime.TypeByExtension(path.Ext(key))}, nil
+}
+
+func (l *Local) Delete(key string) error {
+	target, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(target); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (l *Local) Location(key string) string {
+	target, err := l.path(key)
+	if err != nil {
+		return ""
+	}
+	return target
+}
@@ -0,0 +1,158 @@
+package storage
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeS3 คือ object storage จำลองแบบ path-style ที่ตรวจ header ของ Signature V4 ในทุกคำขอ
+type fakeS3 struct {
+	t       *testing.T
+	mu      sync.Mutex
+	objects map[string]fakeObject
+	paths   []string
+}
+
+type fakeObject struct {
+	body        string
+	contentType string
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=test-key/") ||
+		!strings.Contains(auth, "/us-east-1/s3/aws4_request") ||
+		!strings.Contains(auth, "SignedHeaders=host;x-amz-content-sha256;x-amz-date") {
+		f.t.Errorf("%s %s: unexpected Authorization %q", r.Method, r.URL.Path, auth)
+	}
+	if r.Header.Get("X-Amz-Date") == "" || r.Header.Get("X-Amz-Content-Sha256") == "" {
+		f.t.Errorf("%s %s: missing x-amz headers", r.Method, r.URL.Path)
+	}
+	f.paths = append(f.paths, r.URL.EscapedPath())
+
+	switch r.Method {
+	case http.MethodPut:
+		if len(r.TransferEncoding) > 0 {
+			f.t.Errorf("PUT %s: sent with Transfer-Encoding %v, S3 requires Content-Length", r.URL.Path, r.TransferEncoding)
+		}
+		body, _ := io.ReadAll(r.Body)
+		f.objects[r.URL.Path] = fakeObject{body: string(body), contentType: r.Header.Get("Content-Type")}
+	case http.MethodGet:
+		obj, ok := f.objects[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			io.WriteString(w, "<Error><Code>NoSuchKey</Code></Error>")
+			return
+		}
+		w.Header().Set("Content-Type", obj.contentType)
+		io.WriteString(w, obj.body)
+	case http.MethodDelete:
+		delete(f.objects, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func newTestS3(t *testing.T) (*S3, *fakeS3) {
+	fake := &fakeS3{t: t, objects: map[string]fakeObject{}}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	return &S3{
+		Endpoint:  server.URL,
+		Region:    "us-east-1",
+		Bucket:    "diary",
+		AccessKey: "test-key",
+		SecretKey: "test-secret",
+		PathStyle: true,
+		Client:    server.Client(),
+	}, fake
+}
+
+func TestS3PutOpenDelete(t *testing.T) {
+	s3, _ := newTestS3(t)
+
+	if err := s3.Put("a/report.pdf", strings.NewReader("hello"), 5, "application/pdf"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	reader, info, err := s3.Open("a/report.pdf")
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	body, _ := io.ReadAll(reader)
+	reader.Close()
+	if string(body) != "hello" {
+		t.Errorf("Open body = %q, want %q", body, "hello")
+	}
+	if info.Size != 5 || info.ContentType != "application/pdf" {
+		t.Errorf("Open info = %+v, want size 5 and application/pdf", info)
+	}
+
+	if err := s3.Delete("a/report.pdf"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, _, err := s3.Open("a/report.pdf"); !errors.Is(err, ErrNotExist) {
+		t.Errorf("Open after Delete: err = %v, want ErrNotExist", err)
+	}
+}
+
+func TestS3OpenMissing(t *testing.T) {
+	s3, _ := newTestS3(t)
+
+	if _, _, err := s3.Open("missing.pdf"); !errors.Is(err, ErrNotExist) {
+		t.Errorf("err = %v, want ErrNotExist", err)
+	}
+}
+
+func TestS3PutEmpty(t *testing.T) {
+	s3, fake := newTestS3(t)
+
+	// ห่อ reader เพื่อไม่ให้ net/http รู้ขนาดเอง เหมือนไฟล์จาก multipart
+	body := struct{ io.Reader }{strings.NewReader("")}
+	if err := s3.Put("empty.txt", body, 0, "text/plain"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if _, ok := fake.objects["/diary/empty.txt"]; !ok {
+		t.Errorf("object was not stored, have %v", fake.objects)
+	}
+}
+
+func TestS3KeyEncoding(t *testing.T) {
+	s3, fake := newTestS3(t)
+
+	if err := s3.Put("../บันทึก 1+(2).pdf", strings.NewReader("x"), 1, ""); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	want := "/diary/%E0%B8%9A%E0%B8%B1%E0%B8%99%E0%B8%97%E0%B8%B6%E0%B8%81%201%2B%282%29.pdf"
+	if len(fake.paths) != 1 || fake.paths[0] != want {
+		t.Errorf("request path = %v, want %s", fake.paths, want)
+	}
+	if _, ok := fake.objects["/diary/บันทึก 1+(2).pdf"]; !ok {
+		t.Errorf("object stored under unexpected path, have %v", fake.objects)
+	}
+}
+
+func TestURIEncode(t *testing.T) {
+	tests := map[string]string{
+		"/bucket/a-b_c.d~e":  "/bucket/a-b_c.d~e",
+		"/bucket/a b":        "/bucket/a%20b",
+		"/bucket/a+b=c&d":    "/bucket/a%2Bb%3Dc%26d",
+		"/bucket/dir/ไฟล์":   "/bucket/dir/%E0%B9%84%E0%B8%9F%E0%B8%A5%E0%B9%8C",
+		"/bucket/it's!*.pdf": "/bucket/it%27s%21%2A.pdf",
+	}
+	for in, want := range tests {
+		if got := uriEncode(in); got != want {
+			t.Errorf("uriEncode(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package storage
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"sync"
+)
+
+// ErrNotExist คือข้อผิดพลาดเมื่อไม่พบไฟล์ตาม key
+var ErrNotExist = errors.New("storage: object does not exist")
+
+// Info คือข้อมูลของไฟล์ที่เปิดอ่าน Size เป็น -1 หากไม่ทราบขนาด
+type Info struct {
+	Size        int64
+	ContentType string
+}
+
+// Storage คือที่เก็บไฟล์แนบที่เปลี่ยนได้ เช่น ดิสก์ภายในเครื่อง หรือ object storage ที่รองรับ S3
+// key เป็น path แบบคั่นด้วย "/" และไม่ขึ้นกับ backend
+type Storage interface {
+	Put(key string, r io.Reader, size int64, contentType string) error
+	Open(key string) (io.ReadCloser, Info, error)
+	Delete(key string) error
+	// Location คืนค่าตำแหน่งจริงของไฟล์ เช่น path บนดิสก์ หรือ s3://bucket/key ใช้สำหรับ log และข้อมูลอ้างอิง
+	Location(key string) string
+}
+
+// cleanKey ตัด ".." และ "/" นำหน้าออก เพื่อไม่ให้ key ชี้ออกนอกที่เก็บ
+func cleanKey(key string) (string, error) {
+	cleaned := strings.TrimPrefix(path.Clean("/"+key), "/")
+	if cleaned == "" {
+		return "", errors.New("storage: empty key")
+	}
+	return cleaned, nil
+}
+
+var (
+	mu      sync.RWMutex
+	current Storage
+)
+
+// FromEnv สร้าง Storage จาก STORAGE_DRIVER ("local" หรือ "s3")
+// local ใช้ STORAGE_LOCAL_ROOT (ค่าเริ่มต้น upload/diary)
+// s3 ใช้ S3_ENDPOINT, S3_REGION, S3_BUCKET, S3_ACCESS_KEY_ID, S3_SECRET_ACCESS_KEY และ S3_PATH_STYLE
+func FromEnv() (Storage, error) {
+	switch driver := strings.ToLower(os.Getenv("STORAGE_DRIVER")); driver {
+	case "", "local":
+		root := os.Getenv("STORAGE_LOCAL_ROOT")
+		if root == "" {
+			root = "upload/diary"
+		}
+		return &Local{Root: root}, nil
+	case "s3":
+		s3 := &S3{
+			Endpoint:  os.Getenv("S3_ENDPOINT"),
+			Region:    os.Getenv("S3_REGION"),
+			Bucket:    os.Getenv("S3_BUCKET"),
+			AccessKey: os.Getenv("S3_ACCESS_KEY_ID"),
+			SecretKey: os.Getenv("S3_SECRET_ACCESS_KEY"),
+			PathStyle: !strings.EqualFold(os.Getenv("S3_PATH_STYLE"), "false"),
+		}
+		if s3.Endpoint == "" || s3.Bucket == "" {
+			return nil, errors.New("storage: S3_ENDPOINT and S3_BUCKET are required for the s3 driver")
+		}
+		if s3.Region == "" {
+			s3.Region = "us-east-1"
+		}
+		return s3, nil
+	default:
+		return nil, errors.New("storage: unknown STORAGE_DRIVER " + driver)
+	}
+}
+
+// SetDefault เปลี่ยน Storage ที่ใช้โดย Default
+func SetDefault(s Storage) {
+	mu.Lock()
+	current = s
+	mu.Unlock()
+}
+
+// Default คืนค่า Storage ปัจจุบัน โดยสร้างจาก environment ในครั้งแรกที่เรียก
+// หากตั้งค่าไม่ถูกต้องจะ panic เพราะอัปโหลดหรือดาวน์โหลดไฟล์ไม่ได้เลย
+func Default() Storage {
+	mu.RLock()
+	s := current
+	mu.RUnlock()
+	if s != nil {
+		return s
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if current == nil {
+		s, err := FromEnv()
+		if err != nil {
+			panic(err)
+		}
+		current = s
+	}
+	return current
+}
@@ -0,0 +1,273 @@
+package validation
+
+import (
+	"fmt"
+	"net/mail"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// FieldError คือข้อผิดพลาดของฟิลด์หนึ่ง พร้อมข้อความภาษาไทยและภาษาอังกฤษ
+type FieldError struct {
+	Field     string `json:"field"`
+	Rule      string `json:"rule"`
+	MessageEN string `json:"message_en"`
+	MessageTH string `json:"message_th"`
+}
+
+// Errors คือรายการข้อผิดพลาดของทุกฟิลด์ที่ตรวจสอบไม่ผ่าน
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	parts := make([]string, len(e))
+	for i, fe := range e {
+		parts[i] = fe.Field + ": " + fe.MessageEN
+	}
+	return strings.Join(parts, "; ")
+}
+
+// RuleFunc ตรวจสอบค่าของฟิลด์ตามพารามิเตอร์ของกฎ คืนค่า true หากผ่าน
+type RuleFunc func(value reflect.Value, param string) bool
+
+type rule struct {
+	check RuleFunc
+	en    string
+	th    string
+}
+
+var (
+	rulesMu sync.RWMutex
+	rules   = map[string]rule{
+		"required": {checkRequired, "is required", "จำเป็นต้องระบุ"},
+		"email":    {checkEmail, "must be a valid email address", "รูปแบบอีเมลไม่ถูกต้อง"},
+		"max":      {checkMax, "must be at most %s characters", "ต้องมีความยาวไม่เกิน %s ตัวอักษร"},
+		"min":      {checkMin, "must be at least %s characters", "ต้องมีความยาวอย่างน้อย %s ตัวอักษร"},
+		"oneof":    {checkOneOf, "must be one of: %s", "ต้องเป็นค่าใดค่าหนึ่งต่อไปนี้: %s"},
+		"date":     {checkDate, "must be a date in YYYY-MM-DD format", "ต้องเป็นวันที่ในรูปแบบ YYYY-MM-DD"},
+		"gte":      {checkGte, "must be at least %s", "ต้องมีค่าอย่างน้อย %s"},
+		"lte":      {checkLte, "must be at most %s", "ต้องมีค่าไม่เกิน %s"},
+	}
+)
+
+// RegisterRule เพิ่มกฎตรวจสอบใหม่ที่ใช้ใน tag `validate` ได้ ข้อความรองรับ %s สำหรับพารามิเตอร์
+func RegisterRule(name string, check RuleFunc, messageEN, messageTH string) {
+	rulesMu.Lock()
+	defer rulesMu.Unlock()
+	rules[name] = rule{check, messageEN, messageTH}
+}
+
+// Struct ตรวจสอบทุกฟิลด์ของ struct ตาม tag `validate` เช่น `validate:"required,max=255"`
+// ชื่อฟิลด์ในข้อผิดพลาดใช้ชื่อจาก tag json, query หรือ form ตามลำดับ
+func Struct(v interface{}) Errors {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var errs Errors
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		sf := rt.Field(i)
+		tag := sf.Tag.Get("validate")
+		if tag == "" || tag == "-" || !sf.IsExported() {
+			continue
+		}
+		errs = append(errs, Var(fieldName(sf), rv.Field(i).Interface(), tag)...)
+	}
+	return errs
+}
+
+// Var ตรวจสอบค่าเดียวตามชุดกฎในรูปแบบเดียวกับ tag `validate`
+func Var(field string, value interface{}, tag string) Errors {
+	rv := reflect.ValueOf(value)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			break
+		}
+		rv = rv.Elem()
+	}
+
+	var errs Errors
+	for _, part := range strings.Split(tag, ",") {
+		name, param, _ := strings.Cut(strings.TrimSpace(part), "=")
+		if name == "" {
+			continue
+		}
+		if name == "omitempty" {
+			if isEmpty(rv) {
+				return nil
+			}
+			continue
+		}
+
+		rulesMu.RLock()
+		r, ok := rules[name]
+		rulesMu.RUnlock()
+		if !ok {
+			panic(fmt.Sprintf("validation: unknown rule %q", name))
+		}
+
+		if name != "required" && isEmpty(rv) {
+			continue
+		}
+		if !r.check(rv, param) {
+			errs = append(errs, FieldError{
+				Field:     field,
+				Rule:      name,
+				MessageEN: format(r.en, param),
+				MessageTH: format(r.th, param),
+			})
+			break
+		}
+	}
+	return errs
+}
+
+// Forbidden สร้างข้อผิดพลาดสำหรับฟิลด์ที่ผู้เรียกไม่มีสิทธิ์แก้ไข
+func Forbidden(field string) FieldError {
+	return FieldError{
+		Field:     field,
+		Rule:      "forbidden",
+		MessageEN: "cannot be modified",
+		MessageTH: "ไม่อนุญาตให้แก้ไขฟิลด์นี้",
+	}
+}
+
+// Invalid สร้างข้อผิดพลาดสำหรับค่าที่มีชนิดข้อมูลไม่ถูกต้อง
+func Invalid(field, messageEN, messageTH string) FieldError {
+	return FieldError{
+		Field:     field,
+		Rule:      "type",
+		MessageEN: messageEN,
+		MessageTH: messageTH,
+	}
+}
+
+func fieldName(sf reflect.StructField) string {
+	for _, key := range []string{"json", "query", "form", "params"} {
+		if name, _, _ := strings.Cut(sf.Tag.Get(key), ","); name != "" && name != "-" {
+			return name
+		}
+	}
+	return sf.Name
+}
+
+func format(msg, param string) string {
+	if strings.Contains(msg, "%s") {
+		return fmt.Sprintf(msg, strings.ReplaceAll(param, " ", ", "))
+	}
+	return msg
+}
+
+func isEmpty(v reflect.Value) bool {
+	if !v.IsValid() {
+		return true
+	}
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		return v.IsNil()
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		return v.Len() == 0
+	}
+	if t, ok := v.Interface().(time.Time); ok {
+		return t.IsZero()
+	}
+	return v.IsZero()
+}
+
+func checkRequired(v reflect.Value, _ string) bool {
+	if v.Kind() == reflect.String {
+		return strings.TrimSpace(v.String()) != ""
+	}
+	return !isEmpty(v)
+}
+
+func checkEmail(v reflect.Value, _ string) bool {
+	if v.Kind() != reflect.String {
+		return false
+	}
+	addr, err := mail.ParseAddress(v.String())
+	return err == nil && addr.Address == v.String()
+}
+
+func length(v reflect.Value) (int, bool) {
+	switch v.Kind() {
+	case reflect.String:
+		return utf8.RuneCountInString(v.String()), true
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return v.Len(), true
+	}
+	return 0, false
+}
+
+func checkMax(v reflect.Value, param string) bool {
+	limit, err := strconv.Atoi(param)
+	if err != nil {
+		return false
+	}
+	n, ok := length(v)
+	return ok && n <= limit
+}
+
+func checkMin(v reflect.Value, param string) bool {
+	limit, err := strconv.Atoi(param)
+	if err != nil {
+		return false
+	}
+	n, ok := length(v)
+	return ok && n >= limit
+}
+
+func number(v reflect.Value) (float64, bool) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	}
+	return 0, false
+}
+
+func checkGte(v reflect.Value, param string) bool {
+	limit, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return false
+	}
+	n, ok := number(v)
+	return ok && n >= limit
+}
+
+func checkLte(v reflect.Value, param string) bool {
+	limit, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return false
+	}
+	n, ok := number(v)
+	return ok && n <= limit
+}
+
+func checkOneOf(v reflect.Value, param string) bool {
+	if v.Kind() != reflect.String {
+		return false
+	}
+	for _, option := range strings.Fields(param) {
+		if v.String() == option {
+			return true
+		}
+	}
+	return false
+}
+
+func checkDate(v reflect.Value, _ string) bool {
+	if v.Kind() != reflect.String {
+		return false
+	}
+	_, err := time.Parse("2006-01-02", v.String())
+	return err == nil
+}